@@ -0,0 +1,130 @@
+package build
+
+import (
+	"fmt"
+	"github.com/spf13/cobra"
+	"io"
+	"os"
+	"path/filepath"
+	"podman-compose/cli"
+	"podman-compose/compose"
+	"podman-compose/constant"
+	"podman-compose/registry"
+	"podman-compose/util"
+	"sort"
+)
+
+var buildCmd = &cobra.Command{
+	Use:   "build [SERVICE...]",
+	Short: "Build or rebuild services",
+	Run:   build,
+}
+
+var noCache = false
+var pull = false
+
+func init() {
+	buildCmd.Flags().BoolVarP(&noCache, "no-cache", "", false, "Do not use cache when building the image")
+	buildCmd.Flags().BoolVarP(&pull, "pull", "", false, "Always attempt to pull a newer version of the image")
+	registry.Commands = append(registry.Commands, buildCmd)
+}
+
+func build(cmd *cobra.Command, args []string) {
+	dockerCompose := compose.GetDockerCompose()
+	names := args
+	if len(names) == 0 {
+		for name, service := range dockerCompose.Services {
+			if service.Build != nil {
+				names = append(names, name)
+			}
+		}
+		sort.Strings(names)
+	}
+
+	for _, name := range names {
+		service, exist := dockerCompose.Services[name]
+		if !exist {
+			fmt.Printf("Service %s does not exist\n", name)
+			os.Exit(1)
+		}
+		if service.Build == nil {
+			fmt.Println(compose.FormatServiceName(name) + " uses an image, skipping")
+			continue
+		}
+		err := BuildService(name, service, noCache, pull)
+		if err != nil {
+			fmt.Println(name, ":", err)
+			os.Exit(1)
+		}
+	}
+}
+
+/*
+*
+构建服务的镜像，构建输出带上服务名称前缀
+*/
+func BuildService(serviceName string, service compose.ServiceConfig, noCache bool, pull bool) error {
+	config := service.Build
+	contextDir := compose.ResolvePath(config.Context)
+	if config.Context == "" {
+		contextDir = compose.GetComposeDir()
+	}
+	dockerfile := config.Dockerfile
+	if dockerfile == "" {
+		dockerfile = "Dockerfile"
+	}
+	dockerfile = filepath.ToSlash(filepath.Clean(dockerfile))
+
+	args, err := config.GetArgs()
+	if err != nil {
+		return err
+	}
+	labels, err := compose.ToStringMap(config.Labels, "labels")
+	if err != nil {
+		return err
+	}
+	if labels == nil {
+		labels = map[string]string{}
+	}
+	labels[constant.LabelComposeProject] = compose.GetProjectName()
+	labels[constant.LabelComposeServiceName] = serviceName
+
+	//密钥文件放到构建目录中一起上传，并加入忽略文件，不会被 COPY 到镜像中
+	secrets, err := config.GetSecrets()
+	if err != nil {
+		return err
+	}
+	extra := map[string]string{}
+	var secretOptions []string
+	for _, secret := range secrets {
+		name := ".compose-secrets/" + secret.ID
+		extra[name] = secret.File
+		secretOptions = append(secretOptions, "id="+secret.ID+",src="+name)
+	}
+
+	options := cli.BuildOptions{
+		Dockerfile:  dockerfile,
+		Tags:        []string{compose.GetImageName(serviceName, service)},
+		BuildArgs:   args,
+		Labels:      labels,
+		Target:      config.Target,
+		CacheFrom:   config.CacheFrom,
+		NetworkMode: config.Network,
+		Secrets:     secretOptions,
+		NoCache:     noCache,
+		Pull:        pull,
+	}
+
+	fmt.Println(compose.FormatServiceName(serviceName) + " building...")
+	reader, writer := io.Pipe()
+	go func() {
+		writer.CloseWithError(tarContext(contextDir, dockerfile, extra, writer))
+	}()
+	_, err = cli.Build(reader, options, &util.PrefixWriter{Prefix: compose.FormatServiceName(serviceName) + " | "})
+	reader.Close()
+	if err != nil {
+		return err
+	}
+	fmt.Println(compose.FormatServiceName(serviceName) + " building... " + util.TextColor(32, "done"))
+	return nil
+}
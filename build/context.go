@@ -0,0 +1,258 @@
+package build
+
+import (
+	"archive/tar"
+	"bufio"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// .dockerignore 中的一条规则
+type ignorePattern struct {
+	regexp    *regexp.Regexp
+	exclusion bool
+}
+
+/*
+*
+读取构建目录中的 .dockerignore
+*/
+func readDockerignore(contextDir string) ([]ignorePattern, error) {
+	f, err := os.Open(filepath.Join(contextDir, ".dockerignore"))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	defer f.Close()
+
+	var patterns []ignorePattern
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		exclusion := strings.HasPrefix(line, "!")
+		if exclusion {
+			line = strings.TrimSpace(line[1:])
+		}
+		line = strings.TrimPrefix(filepath.ToSlash(filepath.Clean(line)), "/")
+		re, err := regexp.Compile(patternToRegexp(line))
+		if err != nil {
+			return nil, err
+		}
+		patterns = append(patterns, ignorePattern{re, exclusion})
+	}
+	return patterns, scanner.Err()
+}
+
+// 把 .dockerignore 的通配符转换成正则，** 匹配任意层目录
+func patternToRegexp(pattern string) string {
+	var builder strings.Builder
+	builder.WriteString("^")
+	for i := 0; i < len(pattern); i++ {
+		c := pattern[i]
+		switch c {
+		case '*':
+			if i+1 < len(pattern) && pattern[i+1] == '*' {
+				i++
+				if i+1 < len(pattern) && pattern[i+1] == '/' {
+					i++
+					builder.WriteString("(.*/)?")
+				} else {
+					builder.WriteString(".*")
+				}
+			} else {
+				builder.WriteString("[^/]*")
+			}
+		case '?':
+			builder.WriteString("[^/]")
+		case '\\':
+			if i+1 < len(pattern) {
+				i++
+				builder.WriteString(regexp.QuoteMeta(string(pattern[i])))
+			}
+		case '[':
+			end := strings.IndexByte(pattern[i:], ']')
+			if end == -1 {
+				builder.WriteString(`\[`)
+			} else {
+				builder.WriteString(pattern[i : i+end+1])
+				i += end
+			}
+		default:
+			builder.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	//匹配目录时目录下的文件也一起匹配
+	builder.WriteString("(/.*)?$")
+	return builder.String()
+}
+
+// 文件是否被忽略，后面的规则优先
+func isIgnored(patterns []ignorePattern, path string) bool {
+	ignored := false
+	for _, pattern := range patterns {
+		if pattern.regexp.MatchString(path) {
+			ignored = !pattern.exclusion
+		}
+	}
+	return ignored
+}
+
+func hasExclusions(patterns []ignorePattern) bool {
+	for _, pattern := range patterns {
+		if pattern.exclusion {
+			return true
+		}
+	}
+	return false
+}
+
+// 构建时读取的忽略文件，.containerignore 优先
+var ignoreFiles = []string{".containerignore", ".dockerignore"}
+
+/*
+*
+把构建目录打包成 tar 流，忽略 .dockerignore 中的文件
+dockerfile 即使被忽略也会打包，extra 中的文件追加到 tar 中
+extra 中的文件同时写入上传的忽略文件，避免被 COPY . . 打包进镜像
+*/
+func tarContext(contextDir string, dockerfile string, extra map[string]string, writer io.Writer) error {
+	patterns, err := readDockerignore(contextDir)
+	if err != nil {
+		return err
+	}
+	keepDirs := hasExclusions(patterns)
+	tw := tar.NewWriter(writer)
+
+	err = filepath.Walk(contextDir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(contextDir, path)
+		if err != nil || rel == "." {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		if len(extra) > 0 && contains(ignoreFiles, rel) {
+			//忽略文件在后面追加 extra 后再打包
+			return nil
+		}
+		if rel != dockerfile && rel != ".dockerignore" && isIgnored(patterns, rel) {
+			//有例外规则时目录下的文件仍可能需要打包
+			if info.IsDir() && !keepDirs {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		return addToTar(tw, path, rel, info)
+	})
+	if err != nil {
+		return err
+	}
+
+	if len(extra) > 0 {
+		err = addIgnoreFiles(tw, contextDir, extra)
+		if err != nil {
+			return err
+		}
+	}
+	for name, path := range extra {
+		info, err := os.Stat(path)
+		if err != nil {
+			return err
+		}
+		err = addToTar(tw, path, name, info)
+		if err != nil {
+			return err
+		}
+	}
+	return tw.Close()
+}
+
+// 在构建目录已有的忽略文件后追加 extra 中的文件，没有忽略文件时生成 .dockerignore
+func addIgnoreFiles(tw *tar.Writer, contextDir string, extra map[string]string) error {
+	var lines []string
+	for name := range extra {
+		lines = append(lines, "/"+name)
+	}
+	sort.Strings(lines)
+	added := false
+	for _, name := range ignoreFiles {
+		content, err := os.ReadFile(filepath.Join(contextDir, name))
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return err
+		}
+		err = addContentToTar(tw, name, string(content)+"\n"+strings.Join(lines, "\n")+"\n")
+		if err != nil {
+			return err
+		}
+		added = true
+	}
+	if !added {
+		return addContentToTar(tw, ".dockerignore", strings.Join(lines, "\n")+"\n")
+	}
+	return nil
+}
+
+func addContentToTar(tw *tar.Writer, name string, content string) error {
+	err := tw.WriteHeader(&tar.Header{Name: name, Mode: 0644, Size: int64(len(content)), ModTime: time.Now()})
+	if err != nil {
+		return err
+	}
+	_, err = io.WriteString(tw, content)
+	return err
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func addToTar(tw *tar.Writer, path string, name string, info os.FileInfo) error {
+	link := ""
+	if info.Mode()&os.ModeSymlink != 0 {
+		var err error
+		link, err = os.Readlink(path)
+		if err != nil {
+			return err
+		}
+	}
+	header, err := tar.FileInfoHeader(info, link)
+	if err != nil {
+		return err
+	}
+	header.Name = name
+	if info.IsDir() {
+		header.Name += "/"
+	}
+	err = tw.WriteHeader(header)
+	if err != nil {
+		return err
+	}
+	if !info.Mode().IsRegular() {
+		return nil
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = io.Copy(tw, f)
+	return err
+}
@@ -0,0 +1,55 @@
+package cli
+
+import (
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+// Attach attaches to a running container and streams its output to stdout and
+// stderr until the container exits. If stdin is not nil it is copied to the
+// container over the hijacked connection. attached is called once the
+// connection is established, so the container can be started without losing
+// any output.
+func Attach(nameOrID string, tty bool, stdin io.Reader, stdout, stderr io.Writer, attached func() error) error {
+	conn, err := GetClient(getConnection())
+	if err != nil {
+		return err
+	}
+	params := url.Values{}
+	params.Set("stream", "true")
+	params.Set("stdout", "true")
+	params.Set("stderr", "true")
+	if stdin != nil {
+		params.Set("stdin", "true")
+	}
+	socket, reader, err := conn.DoHijackedRequest(nil, http.MethodPost, "/containers/%s/attach", params, nil, nameOrID)
+	if err != nil {
+		return err
+	}
+	defer socket.Close()
+	if attached != nil {
+		err = attached()
+		if err != nil {
+			return err
+		}
+	}
+	return copyStreams(socket, reader, tty, stdin, stdout, stderr)
+}
+
+// ResizeContainerTTY sets container's TTY height and width in characters
+func ResizeContainerTTY(nameOrID string, height, width int) error {
+	conn, err := GetClient(getConnection())
+	if err != nil {
+		return err
+	}
+	params := url.Values{}
+	params.Set("h", strconv.Itoa(height))
+	params.Set("w", strconv.Itoa(width))
+	response, err := conn.DoRequest(nil, http.MethodPost, "/containers/%s/resize", params, nameOrID)
+	if err != nil {
+		return err
+	}
+	return response.Process(nil)
+}
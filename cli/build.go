@@ -0,0 +1,137 @@
+package cli
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	jsoniter "github.com/json-iterator/go"
+	"github.com/pkg/errors"
+)
+
+// BuildOptions describe the options used when building an image
+type BuildOptions struct {
+	// Dockerfile is the path of the Dockerfile within the build context
+	Dockerfile string
+	// Tags are the names given to the built image
+	Tags []string
+	// BuildArgs are the build-time variables
+	BuildArgs map[string]string
+	// Labels are set on the built image
+	Labels map[string]string
+	// Target is the stage to build in a multi-stage Dockerfile
+	Target string
+	// CacheFrom are images used as cache sources
+	CacheFrom []string
+	// NetworkMode sets the networking mode for RUN instructions
+	NetworkMode string
+	// Secrets are the build secrets in the form id=<id>,src=<path in context>
+	Secrets []string
+	// NoCache do not use the cache when building the image
+	NoCache bool
+	// Pull always attempts to pull a newer version of the base images
+	Pull bool
+}
+
+// buildResponse is a single line of the build output stream
+type buildResponse struct {
+	Stream string `json:"stream,omitempty"`
+	Error  string `json:"error,omitempty"`
+	ID     string `json:"id,omitempty"`
+	Aux    *struct {
+		ID string `json:"ID"`
+	} `json:"aux,omitempty"`
+}
+
+// Build creates an image using the tar stream of the build context. The build output is
+// written to stdout as it arrives, the id of the built image is returned.
+func Build(buildContext io.Reader, options BuildOptions, stdout io.Writer) (string, error) {
+	conn, err := GetClient(getConnection())
+	if err != nil {
+		return "", err
+	}
+	params := url.Values{}
+	if options.Dockerfile != "" {
+		params.Set("dockerfile", options.Dockerfile)
+	}
+	for _, tag := range options.Tags {
+		params.Add("t", tag)
+	}
+	if len(options.BuildArgs) > 0 {
+		args, err := jsoniter.MarshalToString(options.BuildArgs)
+		if err != nil {
+			return "", err
+		}
+		params.Set("buildargs", args)
+	}
+	if len(options.Labels) > 0 {
+		labels, err := jsoniter.MarshalToString(options.Labels)
+		if err != nil {
+			return "", err
+		}
+		params.Set("labels", labels)
+	}
+	if options.Target != "" {
+		params.Set("target", options.Target)
+	}
+	if len(options.CacheFrom) > 0 {
+		cacheFrom, err := jsoniter.MarshalToString(options.CacheFrom)
+		if err != nil {
+			return "", err
+		}
+		params.Set("cachefrom", cacheFrom)
+	}
+	if options.NetworkMode != "" {
+		params.Set("networkmode", options.NetworkMode)
+	}
+	if len(options.Secrets) > 0 {
+		secrets, err := jsoniter.MarshalToString(options.Secrets)
+		if err != nil {
+			return "", err
+		}
+		params.Set("secrets", secrets)
+	}
+	params.Set("nocache", strconv.FormatBool(options.NoCache))
+	params.Set("pull", strconv.FormatBool(options.Pull))
+	params.Set("rm", "true")
+
+	headers := http.Header{}
+	headers.Set("Content-Type", "application/x-tar")
+	response, err := conn.DoRequestWithHeaders(buildContext, http.MethodPost, "/build", params, headers)
+	if err != nil {
+		return "", err
+	}
+	defer response.Body.Close()
+	if !response.IsSuccess() {
+		return "", response.Process(nil)
+	}
+
+	var id string
+	decoder := json.NewDecoder(response.Body)
+	for {
+		var line buildResponse
+		err = decoder.Decode(&line)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return "", errors.Wrap(err, "decoding build output")
+		}
+		if line.Error != "" {
+			return "", errors.New(strings.TrimSpace(line.Error))
+		}
+		if line.Stream != "" && stdout != nil {
+			io.WriteString(stdout, line.Stream)
+		}
+		if line.Aux != nil && line.Aux.ID != "" {
+			id = line.Aux.ID
+		}
+		if line.ID != "" {
+			id = line.ID
+		}
+	}
+	return id, nil
+}
@@ -210,6 +210,11 @@ func unixClient(_url *url.URL) (*http.Client, error) {
 
 // DoRequest assembles the http request and returns the response
 func (c *Connection) DoRequest(httpBody io.Reader, httpMethod, endpoint string, queryParams url.Values, pathValues ...string) (*APIResponse, error) {
+	return c.DoRequestWithHeaders(httpBody, httpMethod, endpoint, queryParams, nil, pathValues...)
+}
+
+// DoRequestWithHeaders assembles the http request with the given headers and returns the response
+func (c *Connection) DoRequestWithHeaders(httpBody io.Reader, httpMethod, endpoint string, queryParams url.Values, headers http.Header, pathValues ...string) (*APIResponse, error) {
 	var (
 		err      error
 		response *http.Response
@@ -230,6 +235,11 @@ func (c *Connection) DoRequest(httpBody io.Reader, httpMethod, endpoint string,
 	if len(queryParams) > 0 {
 		req.URL.RawQuery = queryParams.Encode()
 	}
+	for key, values := range headers {
+		for _, value := range values {
+			req.Header.Add(key, value)
+		}
+	}
 	// Give the Do three chances in the case of a comm/service hiccup
 	for i := 0; i < 3; i++ {
 		response, err = c.client.Do(req) // nolint
@@ -241,6 +251,56 @@ func (c *Connection) DoRequest(httpBody io.Reader, httpMethod, endpoint string,
 	return &APIResponse{response, req}, err
 }
 
+// DoHijackedRequest sends the request over a raw connection to the service and
+// returns the connection once the response headers were read, so that the
+// caller can use it as a bidirectional stream (exec, attach).
+func (c *Connection) DoHijackedRequest(httpBody io.Reader, httpMethod, endpoint string, queryParams url.Values, headers http.Header, pathValues ...string) (net.Conn, *bufio.Reader, error) {
+	safePathValues := make([]interface{}, len(pathValues))
+	for i, pv := range pathValues {
+		safePathValues[i] = url.PathEscape(pv)
+	}
+	e := basePath.String() + fmt.Sprintf(endpoint, safePathValues...)
+	req, err := http.NewRequest(httpMethod, e, httpBody)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(queryParams) > 0 {
+		req.URL.RawQuery = queryParams.Encode()
+	}
+	for key, values := range headers {
+		for _, value := range values {
+			req.Header.Add(key, value)
+		}
+	}
+	req.Header.Set("Connection", "Upgrade")
+	req.Header.Set("Upgrade", "tcp")
+
+	transport, ok := c.client.Transport.(*http.Transport)
+	if !ok || transport.DialContext == nil {
+		return nil, nil, errors.New("connection does not support hijacking")
+	}
+	conn, err := transport.DialContext(context.Background(), "tcp", c._url.Host)
+	if err != nil {
+		return nil, nil, err
+	}
+	err = req.Write(conn)
+	if err != nil {
+		conn.Close()
+		return nil, nil, err
+	}
+	reader := bufio.NewReader(conn)
+	response, err := http.ReadResponse(reader, req)
+	if err != nil {
+		conn.Close()
+		return nil, nil, err
+	}
+	if response.StatusCode != http.StatusSwitchingProtocols && response.StatusCode/100 != 2 {
+		defer conn.Close()
+		return nil, nil, APIResponse{response, req}.Process(nil)
+	}
+	return conn, reader, nil
+}
+
 // FiltersToString converts our typical filter format of a
 // map[string][]string to a query/html safe string.
 func FiltersToString(filters map[string][]string) (string, error) {
@@ -0,0 +1,181 @@
+package cli
+
+import (
+	"bufio"
+	"encoding/binary"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	jsoniter "github.com/json-iterator/go"
+	"github.com/pkg/errors"
+)
+
+// ExecCreateConfig contains the configuration of an exec session
+type ExecCreateConfig struct {
+	// AttachStdin attaches stdin to the exec session
+	AttachStdin bool `json:"AttachStdin"`
+	// AttachStdout attaches stdout to the exec session
+	AttachStdout bool `json:"AttachStdout"`
+	// AttachStderr attaches stderr to the exec session
+	AttachStderr bool `json:"AttachStderr"`
+	// Tty allocates a pseudo-TTY for the exec session
+	Tty bool `json:"Tty"`
+	// Env is a set of "KEY=VALUE" environment variables
+	Env []string `json:"Env,omitempty"`
+	// Cmd is the command that will be invoked
+	Cmd []string `json:"Cmd"`
+	// Privileged gives extended privileges to the exec session
+	Privileged bool `json:"Privileged"`
+	// User the exec session will be run as
+	User string `json:"User,omitempty"`
+	// WorkingDir the exec session will be run in
+	WorkingDir string `json:"WorkingDir,omitempty"`
+}
+
+// InspectExecSession contains information about a given exec session
+type InspectExecSession struct {
+	// ExitCode is the exit code of the exec session. Will be set to 0 if
+	// the exec session has not yet exited.
+	ExitCode int `json:"ExitCode"`
+	// ID is the ID of the exec session.
+	ID string `json:"ID"`
+	// Running is whether the exec session is running.
+	Running bool `json:"Running"`
+}
+
+// ExecCreate creates a new exec session in an existing container.
+// The exec session will not be started; that is done with ExecStartAndAttach.
+// Returns ID of new exec session, or an error if one occurred.
+func ExecCreate(nameOrID string, config ExecCreateConfig) (string, error) {
+	conn, err := GetClient(getConnection())
+	if err != nil {
+		return "", err
+	}
+	requestJSON, err := jsoniter.MarshalToString(config)
+	if err != nil {
+		return "", err
+	}
+	response, err := conn.DoRequest(strings.NewReader(requestJSON), http.MethodPost, "/containers/%s/exec", nil, nameOrID)
+	if err != nil {
+		return "", err
+	}
+	created := struct {
+		ID string `json:"Id"`
+	}{}
+	return created.ID, response.Process(&created)
+}
+
+// ExecInspect inspects an existing exec session, returning detailed information
+// about it.
+func ExecInspect(sessionID string) (*InspectExecSession, error) {
+	conn, err := GetClient(getConnection())
+	if err != nil {
+		return nil, err
+	}
+	response, err := conn.DoRequest(nil, http.MethodGet, "/exec/%s/json", nil, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	inspect := &InspectExecSession{}
+	return inspect, response.Process(inspect)
+}
+
+// ExecResize resizes the TTY of the given exec session.
+func ExecResize(sessionID string, height, width int) error {
+	conn, err := GetClient(getConnection())
+	if err != nil {
+		return err
+	}
+	params := url.Values{}
+	params.Set("h", strconv.Itoa(height))
+	params.Set("w", strconv.Itoa(width))
+	response, err := conn.DoRequest(nil, http.MethodPost, "/exec/%s/resize", params, sessionID)
+	if err != nil {
+		return err
+	}
+	return response.Process(nil)
+}
+
+// ExecStartAndAttach starts the exec session and streams its output to stdout
+// and stderr until it exits. If stdin is not nil it is copied to the session
+// over the hijacked connection. started is called once the session is running.
+func ExecStartAndAttach(sessionID string, tty bool, stdin io.Reader, stdout, stderr io.Writer, started func()) error {
+	conn, err := GetClient(getConnection())
+	if err != nil {
+		return err
+	}
+	body, err := jsoniter.MarshalToString(map[string]bool{"Detach": false, "Tty": tty})
+	if err != nil {
+		return err
+	}
+	headers := http.Header{}
+	headers.Set("Content-Type", "application/json")
+	socket, reader, err := conn.DoHijackedRequest(strings.NewReader(body), http.MethodPost, "/exec/%s/start", nil, headers, sessionID)
+	if err != nil {
+		return err
+	}
+	defer socket.Close()
+	if started != nil {
+		started()
+	}
+	return copyStreams(socket, reader, tty, stdin, stdout, stderr)
+}
+
+// copyStreams copies stdin to the hijacked connection and its output to stdout
+// and stderr. Without a TTY the output is multiplexed.
+func copyStreams(socket net.Conn, reader *bufio.Reader, tty bool, stdin io.Reader, stdout, stderr io.Writer) error {
+	var err error
+	if stdin != nil {
+		go func() {
+			io.Copy(socket, stdin)
+			//输入结束后关闭写端，让容器内的进程读到 EOF
+			if closer, ok := socket.(interface{ CloseWrite() error }); ok {
+				closer.CloseWrite()
+			}
+		}()
+	}
+
+	if tty {
+		_, err = io.Copy(stdout, reader)
+	} else {
+		err = demuxCopy(reader, stdout, stderr)
+	}
+	if err != nil && !errors.Is(err, net.ErrClosed) {
+		return err
+	}
+	return nil
+}
+
+// demuxCopy copies a multiplexed stream (see demuxStream) to stdout and stderr
+func demuxCopy(reader *bufio.Reader, stdout, stderr io.Writer) error {
+	header := make([]byte, 8)
+	for {
+		_, err := io.ReadFull(reader, header)
+		if err != nil {
+			if err == io.EOF || err == io.ErrUnexpectedEOF {
+				return nil
+			}
+			return err
+		}
+		size := binary.BigEndian.Uint32(header[4:8])
+		frame := make([]byte, size)
+		_, err = io.ReadFull(reader, frame)
+		if err != nil {
+			return err
+		}
+		switch header[0] {
+		case 1:
+			stdout.Write(frame)
+		case 2:
+			stderr.Write(frame)
+		case 3:
+			return errors.New("error from service in stream: " + string(frame))
+		default:
+			return errors.Errorf("unrecognized stream type %d in exec stream", header[0])
+		}
+	}
+}
@@ -0,0 +1,126 @@
+package cli
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/pkg/errors"
+)
+
+// pullReport is a single line of the pull progress stream
+type pullReport struct {
+	// Stream used to provide output from c/image
+	Stream string `json:"stream,omitempty"`
+	// Error contains text of errors from c/image
+	Error string `json:"error,omitempty"`
+	// Images contains the ID's of the images pulled
+	Images []string `json:"images,omitempty"`
+	// ID contains image id (retained for backwards compatibility)
+	ID string `json:"id,omitempty"`
+}
+
+// Pull is the binding for libpod's v2 endpoints for pulling images.  Note that
+// `rawImage` must be a reference to a registry (i.e., of docker transport or be
+// normalized to one).  Other transports are rejected as they do not make sense
+// in a remote context. Progress is written to progress as it arrives, the ids
+// of the pulled images are returned.
+func Pull(rawImage string, quiet *bool, progress io.Writer) ([]string, error) {
+	conn, err := GetClient(getConnection())
+	if err != nil {
+		return nil, err
+	}
+	params := url.Values{}
+	params.Set("reference", rawImage)
+	if quiet != nil {
+		params.Set("quiet", strconv.FormatBool(*quiet))
+	}
+	response, err := conn.DoRequest(nil, http.MethodPost, "/images/pull", params)
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+	if !response.IsSuccess() {
+		return nil, response.Process(nil)
+	}
+
+	var images []string
+	var pullErrors []error
+	dec := json.NewDecoder(response.Body)
+	for {
+		var report pullReport
+		if err := dec.Decode(&report); err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			report.Error = err.Error() + "\n"
+		}
+
+		switch {
+		case report.Stream != "":
+			if progress != nil {
+				io.WriteString(progress, report.Stream)
+			}
+		case report.Error != "":
+			pullErrors = append(pullErrors, errors.New(report.Error))
+		case len(report.Images) > 0:
+			images = report.Images
+		case report.ID != "":
+		default:
+			return images, errors.Errorf("failed to parse pull results stream, unexpected input: %v", report)
+		}
+	}
+	if len(pullErrors) > 0 {
+		return images, pullErrors[len(pullErrors)-1]
+	}
+	return images, nil
+}
+
+// ImageExists returns true if a given image exists in local storage
+func ImageExists(nameOrID string) (bool, error) {
+	conn, err := GetClient(getConnection())
+	if err != nil {
+		return false, err
+	}
+	response, err := conn.DoRequest(nil, http.MethodGet, "/images/%s/exists", nil, nameOrID)
+	if err != nil {
+		return false, err
+	}
+	defer response.Body.Close()
+	if response.IsSuccess() {
+		return true, nil
+	}
+	if response.StatusCode == http.StatusNotFound {
+		return false, nil
+	}
+	return false, response.Process(nil)
+}
+
+// ImageRemoveReport is the response of removing an image
+type ImageRemoveReport struct {
+	// Deleted image IDs
+	Deleted []string `json:"Deleted,omitempty"`
+	// Untagged image names
+	Untagged []string `json:"Untagged,omitempty"`
+}
+
+// RemoveImage removes an image from local storage. The force bool removes
+// the image even if it is used by containers.
+func RemoveImage(nameOrID string, force *bool) (*ImageRemoveReport, error) {
+	conn, err := GetClient(getConnection())
+	if err != nil {
+		return nil, err
+	}
+	params := url.Values{}
+	if force != nil {
+		params.Set("force", strconv.FormatBool(*force))
+	}
+	report := ImageRemoveReport{}
+	response, err := conn.DoRequest(nil, http.MethodDelete, "/images/%s", params, nameOrID)
+	if err != nil {
+		return nil, err
+	}
+	return &report, response.Process(&report)
+}
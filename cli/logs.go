@@ -0,0 +1,130 @@
+package cli
+
+import (
+	"bufio"
+	"encoding/binary"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// LogOptions describe finer control of log content or
+// how the content is formatted.
+type LogOptions struct {
+	Follow     *bool
+	Since      *string
+	Stderr     *bool
+	Stdout     *bool
+	Tail       *string
+	Timestamps *bool
+	Until      *string
+}
+
+// Logs obtains a container's logs given the options provided.  The logs are then sent to the
+// stdout|stderr channels as strings, one line at a time.
+func Logs(nameOrID string, options LogOptions, stdoutChan, stderrChan chan string) error {
+	conn, err := GetClient(getConnection())
+	if err != nil {
+		return err
+	}
+	params := url.Values{}
+	if options.Follow != nil {
+		params.Set("follow", strconv.FormatBool(*options.Follow))
+	}
+	if options.Since != nil {
+		params.Set("since", *options.Since)
+	}
+	if options.Until != nil {
+		params.Set("until", *options.Until)
+	}
+	if options.Tail != nil {
+		params.Set("tail", *options.Tail)
+	}
+	if options.Timestamps != nil {
+		params.Set("timestamps", strconv.FormatBool(*options.Timestamps))
+	}
+	// The API requires either stdout|stderr be used. If neither are specified, we specify stdout
+	if options.Stdout == nil && options.Stderr == nil {
+		params.Set("stdout", strconv.FormatBool(true))
+	} else {
+		if options.Stdout != nil {
+			params.Set("stdout", strconv.FormatBool(*options.Stdout))
+		}
+		if options.Stderr != nil {
+			params.Set("stderr", strconv.FormatBool(*options.Stderr))
+		}
+	}
+	response, err := conn.DoRequest(nil, http.MethodGet, "/containers/%s/logs", params, nameOrID)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+	if !response.IsSuccess() {
+		return response.Process(nil)
+	}
+	return demuxStream(response.Body, stdoutChan, stderrChan)
+}
+
+/*
+*
+The stream is multiplexed: each frame starts with an 8 byte header,
+byte 0 is the stream (1 stdout, 2 stderr, 3 error) and bytes 4-7 are
+the big endian size of the payload that follows.
+*/
+func demuxStream(body io.Reader, stdoutChan, stderrChan chan string) error {
+	reader := bufio.NewReader(body)
+	header := make([]byte, 8)
+	var stdoutBuf, stderrBuf strings.Builder
+	for {
+		_, err := io.ReadFull(reader, header)
+		if err != nil {
+			if err == io.EOF || err == io.ErrUnexpectedEOF {
+				flushLine(&stdoutBuf, stdoutChan)
+				flushLine(&stderrBuf, stderrChan)
+				return nil
+			}
+			return err
+		}
+		size := binary.BigEndian.Uint32(header[4:8])
+		frame := make([]byte, size)
+		_, err = io.ReadFull(reader, frame)
+		if err != nil {
+			return err
+		}
+		switch header[0] {
+		case 1:
+			sendLines(&stdoutBuf, string(frame), stdoutChan)
+		case 2:
+			sendLines(&stderrBuf, string(frame), stderrChan)
+		case 3:
+			return errors.New("error from service in stream: " + string(frame))
+		default:
+			return errors.Errorf("unrecognized stream type %d in log stream", header[0])
+		}
+	}
+}
+
+// sendLines sends complete lines to the channel and keeps the rest in buf
+func sendLines(buf *strings.Builder, frame string, ch chan string) {
+	buf.WriteString(frame)
+	content := buf.String()
+	lines := strings.Split(content, "\n")
+	buf.Reset()
+	buf.WriteString(lines[len(lines)-1])
+	for _, line := range lines[:len(lines)-1] {
+		if ch != nil {
+			ch <- strings.TrimSuffix(line, "\r")
+		}
+	}
+}
+
+func flushLine(buf *strings.Builder, ch chan string) {
+	if buf.Len() > 0 && ch != nil {
+		ch <- buf.String()
+	}
+	buf.Reset()
+}
@@ -0,0 +1,150 @@
+package cli
+
+import (
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	jsoniter "github.com/json-iterator/go"
+)
+
+// Network describes the Network attributes as used by the libpod API
+type Network struct {
+	// Name of the Network.
+	Name string `json:"name"`
+	// ID of the Network.
+	ID string `json:"id,omitempty"`
+	// Driver for this Network, e.g. bridge, macvlan...
+	Driver string `json:"driver,omitempty"`
+	// NetworkInterface is the network interface name on the host.
+	NetworkInterface string `json:"network_interface,omitempty"`
+	// Subnets to use for this network.
+	Subnets []Subnet `json:"subnets,omitempty"`
+	// IPv6Enabled if set to true an ipv6 subnet should be created for this net.
+	IPv6Enabled bool `json:"ipv6_enabled"`
+	// Internal is whether the Network should not have external routes
+	// to public or other Networks.
+	Internal bool `json:"internal"`
+	// DNSEnabled is whether name resolution is active for container on
+	// this Network. Only supported with the bridge driver.
+	DNSEnabled bool `json:"dns_enabled"`
+	// Labels is a set of key-value labels that have been applied to the
+	// Network.
+	Labels map[string]string `json:"labels,omitempty"`
+	// Options is a set of key-value options that have been applied to
+	// the Network.
+	Options map[string]string `json:"options,omitempty"`
+	// IPAMOptions contains options used for the ip assignment.
+	IPAMOptions map[string]string `json:"ipam_options,omitempty"`
+}
+
+// Subnet for a network
+type Subnet struct {
+	// Subnet for this Network in CIDR form.
+	Subnet string `json:"subnet"`
+	// Gateway IP for this Network.
+	Gateway string `json:"gateway,omitempty"`
+	// LeaseRange contains the range where IP are leased. Optional.
+	LeaseRange *LeaseRange `json:"lease_range,omitempty"`
+}
+
+// LeaseRange contains the range where IP are leased.
+type LeaseRange struct {
+	// StartIP first IP in the subnet which should be used to assign ips.
+	StartIP string `json:"start_ip,omitempty"`
+	// EndIP last IP in the subnet which should be used to assign ips.
+	EndIP string `json:"end_ip,omitempty"`
+}
+
+// CreateNetwork makes a new CNI network configuration
+func CreateNetwork(network Network) (*Network, error) {
+	var created Network
+	conn, err := GetClient(getConnection())
+	if err != nil {
+		return nil, err
+	}
+	networkConfig, err := jsoniter.MarshalToString(network)
+	if err != nil {
+		return nil, err
+	}
+	response, err := conn.DoRequest(strings.NewReader(networkConfig), http.MethodPost, "/networks/create", nil)
+	if err != nil {
+		return nil, err
+	}
+	return &created, response.Process(&created)
+}
+
+// InspectNetwork displays the raw network configuration.
+func InspectNetwork(nameOrID string) (*Network, error) {
+	var network Network
+	conn, err := GetClient(getConnection())
+	if err != nil {
+		return nil, err
+	}
+	response, err := conn.DoRequest(nil, http.MethodGet, "/networks/%s/json", nil, nameOrID)
+	if err != nil {
+		return &network, err
+	}
+	return &network, response.Process(&network)
+}
+
+// NetworkExists returns true if a given network exists
+func NetworkExists(nameOrID string) (bool, error) {
+	conn, err := GetClient(getConnection())
+	if err != nil {
+		return false, err
+	}
+	response, err := conn.DoRequest(nil, http.MethodGet, "/networks/%s/exists", nil, nameOrID)
+	if err != nil {
+		return false, err
+	}
+	defer response.Body.Close()
+	if response.IsSuccess() {
+		return true, nil
+	}
+	if response.StatusCode == http.StatusNotFound {
+		return false, nil
+	}
+	return false, response.Process(nil)
+}
+
+// ListNetworks returns the network configurations for existing networks.
+func ListNetworks(filters map[string][]string) ([]Network, error) {
+	var netList []Network
+	conn, err := GetClient(getConnection())
+	if err != nil {
+		return nil, err
+	}
+	params := url.Values{}
+	if len(filters) > 0 {
+		strFilters, err := FiltersToString(filters)
+		if err != nil {
+			return nil, err
+		}
+		params.Set("filters", strFilters)
+	}
+	response, err := conn.DoRequest(nil, http.MethodGet, "/networks/json", params)
+	if err != nil {
+		return netList, err
+	}
+	return netList, response.Process(&netList)
+}
+
+// RemoveNetwork removes a network from the system. The force bool removes
+// the network even if it is in use by containers.
+func RemoveNetwork(nameOrID string, force *bool) error {
+	conn, err := GetClient(getConnection())
+	if err != nil {
+		return err
+	}
+	params := url.Values{}
+	if force != nil {
+		params.Set("force", strconv.FormatBool(*force))
+	}
+	response, err := conn.DoRequest(nil, http.MethodDelete, "/networks/%s", params, nameOrID)
+	if err != nil {
+		return err
+	}
+	return response.Process(nil)
+}
@@ -7,6 +7,10 @@ import (
 	"net/url"
 	"os"
 	"strconv"
+	"strings"
+	"sync"
+
+	jsoniter "github.com/json-iterator/go"
 )
 
 // ContainerSize holds the size of the container's root filesystem and top
@@ -37,6 +41,8 @@ type ListContainer struct {
 	Mounts []string
 	// The names assigned to the container
 	Names []string
+	// The network names assigned to the container
+	Networks []string
 	// Namespaces the container belongs to.  Requires the
 	// namespace boolean to be true
 	// The process id of the container
@@ -84,9 +90,41 @@ func (r *PortMapping) String() string {
 type ContainerDetail struct {
 	Image      string                      `json:"Image"`
 	ImageName  string                      `json:"ImageName"`
+	State      *InspectContainerState      `json:"State"`
 	HostConfig *InspectContainerHostConfig `json:"HostConfig"`
 }
 
+// InspectContainerState provides a detailed record of a container's current
+// state.
+type InspectContainerState struct {
+	Status   string              `json:"Status"`
+	Running  bool                `json:"Running"`
+	ExitCode int32               `json:"ExitCode"`
+	Health   *HealthCheckResults `json:"Health,omitempty"`
+}
+
+// HealthCheckResults describes the results/logs from a healthcheck
+type HealthCheckResults struct {
+	// Status starting, healthy or unhealthy
+	Status string `json:"Status"`
+	// FailingStreak is the number of consecutive failed healthchecks
+	FailingStreak int `json:"FailingStreak"`
+	// Log describes healthcheck attempts and results
+	Log []HealthCheckLog `json:"Log"`
+}
+
+// HealthCheckLog describes the results of a single healthcheck
+type HealthCheckLog struct {
+	// Start time as string
+	Start string `json:"Start"`
+	// End time as a string
+	End string `json:"End"`
+	// Exitcode is 0 or 1
+	ExitCode int `json:"ExitCode"`
+	// Output is the stdout/stderr from the healthcheck command
+	Output string `json:"Output"`
+}
+
 type InspectContainerHostConfig struct {
 	// RestartPolicy contains the container's restart policy.
 	RestartPolicy *InspectRestartPolicy `json:"RestartPolicy"`
@@ -103,17 +141,23 @@ type ImageData struct {
 }
 
 var connection context.Context
+var connectionOnce sync.Once
 
-func init() {
-	var err error
-	connection, err = NewConnection(context.Background(), "unix:///run/podman/podman.sock")
-	if err != nil {
-		fmt.Println(err)
-		os.Exit(1)
-	}
+// getConnection connects to the podman service on first use, so that code
+// which never talks to podman does not need a running service.
+func getConnection() context.Context {
+	connectionOnce.Do(func() {
+		var err error
+		connection, err = NewConnection(context.Background(), "unix:///run/podman/podman.sock")
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+	})
+	return connection
 }
 func List(filters map[string][]string, all *bool, last *int, pod, size, sync *bool) ([]ListContainer, error) { // nolint:typecheck
-	conn, err := GetClient(connection)
+	conn, err := GetClient(getConnection())
 	if err != nil {
 		return nil, err
 	}
@@ -149,7 +193,7 @@ func List(filters map[string][]string, all *bool, last *int, pod, size, sync *bo
 }
 
 func Inspect(nameOrID string, size *bool) (*ContainerDetail, error) {
-	conn, err := GetClient(connection)
+	conn, err := GetClient(getConnection())
 	if err != nil {
 		return nil, err
 	}
@@ -166,7 +210,7 @@ func Inspect(nameOrID string, size *bool) (*ContainerDetail, error) {
 }
 
 func GetImage(nameOrID string, size *bool) (*ImageData, error) {
-	conn, err := GetClient(connection)
+	conn, err := GetClient(getConnection())
 	if err != nil {
 		return nil, err
 	}
@@ -186,7 +230,7 @@ func GetImage(nameOrID string, size *bool) (*ImageData, error) {
 // that the container should be removed forcibly (example, even it is running).  The volumes
 // bool dictates that a container's volumes should also be removed.
 func Remove(nameOrID string, force, volumes *bool) error {
-	conn, err := GetClient(connection)
+	conn, err := GetClient(getConnection())
 	if err != nil {
 		return err
 	}
@@ -204,8 +248,26 @@ func Remove(nameOrID string, force, volumes *bool) error {
 	return response.Process(nil)
 }
 
+// CreateContainer creates a container from the given spec generator.
+func CreateContainer(spec *SpecGenerator) (ContainerCreateResponse, error) {
+	var ccr ContainerCreateResponse
+	conn, err := GetClient(getConnection())
+	if err != nil {
+		return ccr, err
+	}
+	specString, err := jsoniter.MarshalToString(spec)
+	if err != nil {
+		return ccr, err
+	}
+	response, err := conn.DoRequest(strings.NewReader(specString), http.MethodPost, "/containers/create", nil)
+	if err != nil {
+		return ccr, err
+	}
+	return ccr, response.Process(&ccr)
+}
+
 func Start(nameOrID string, detachKeys *string) error {
-	conn, err := GetClient(connection)
+	conn, err := GetClient(getConnection())
 	if err != nil {
 		return err
 	}
@@ -219,3 +281,107 @@ func Start(nameOrID string, detachKeys *string) error {
 	}
 	return response.Process(nil)
 }
+
+// Stop stops a running container.  The timeout is optional. The nameOrID can be a container name
+// or a partial/full ID
+func Stop(nameOrID string, timeout *uint) error {
+	conn, err := GetClient(getConnection())
+	if err != nil {
+		return err
+	}
+	params := url.Values{}
+	if timeout != nil {
+		params.Set("timeout", strconv.Itoa(int(*timeout)))
+	}
+	response, err := conn.DoRequest(nil, http.MethodPost, "/containers/%s/stop", params, nameOrID)
+	if err != nil {
+		return err
+	}
+	return response.Process(nil)
+}
+
+// Kill sends a given signal to a given container.  The signal should be the string
+// representation of a signal like 'SIGKILL'. The nameOrID can be a container name
+// or a partial/full ID
+func Kill(nameOrID string, signal *string) error {
+	conn, err := GetClient(getConnection())
+	if err != nil {
+		return err
+	}
+	params := url.Values{}
+	if signal != nil {
+		params.Set("signal", *signal)
+	}
+	response, err := conn.DoRequest(nil, http.MethodPost, "/containers/%s/kill", params, nameOrID)
+	if err != nil {
+		return err
+	}
+	return response.Process(nil)
+}
+
+// Restart restarts a running container. The timeout is optional and is the
+// number of seconds to wait for the container to stop before it is killed.
+// The nameOrID can be a container name or a partial/full ID
+func Restart(nameOrID string, timeout *uint) error {
+	conn, err := GetClient(getConnection())
+	if err != nil {
+		return err
+	}
+	params := url.Values{}
+	if timeout != nil {
+		params.Set("t", strconv.Itoa(int(*timeout)))
+	}
+	response, err := conn.DoRequest(nil, http.MethodPost, "/containers/%s/restart", params, nameOrID)
+	if err != nil {
+		return err
+	}
+	return response.Process(nil)
+}
+
+// Pause pauses a given container.  The nameOrID can be a container name
+// or a partial/full ID.
+func Pause(nameOrID string) error {
+	conn, err := GetClient(getConnection())
+	if err != nil {
+		return err
+	}
+	response, err := conn.DoRequest(nil, http.MethodPost, "/containers/%s/pause", nil, nameOrID)
+	if err != nil {
+		return err
+	}
+	return response.Process(nil)
+}
+
+// Unpause resumes the given paused container.  The nameOrID can be a container name
+// or a partial/full ID.
+func Unpause(nameOrID string) error {
+	conn, err := GetClient(getConnection())
+	if err != nil {
+		return err
+	}
+	response, err := conn.DoRequest(nil, http.MethodPost, "/containers/%s/unpause", nil, nameOrID)
+	if err != nil {
+		return err
+	}
+	return response.Process(nil)
+}
+
+// Wait blocks until the given container reaches a condition. If not provided, the condition will
+// default to stopped.  If the condition is stopped, an exit code for the container will be provided. The
+// nameOrID can be a container name or a partial/full ID.
+func Wait(nameOrID string, condition *string) (int32, error) { // nolint
+	var exitCode int32
+	conn, err := GetClient(getConnection())
+	if err != nil {
+		return exitCode, err
+	}
+	params := url.Values{}
+	if condition != nil {
+		params.Set("condition", *condition)
+	}
+	response, err := conn.DoRequest(nil, http.MethodPost, "/containers/%s/wait", params, nameOrID)
+	if err != nil {
+		return exitCode, err
+	}
+	return exitCode, response.Process(&exitCode)
+}
@@ -0,0 +1,191 @@
+package cli
+
+import "syscall"
+
+// SpecGenerator is the subset of the libpod container create payload
+// used by podman-compose.
+type SpecGenerator struct {
+	// Name is the name the container will be given.
+	Name string `json:"name,omitempty"`
+	// Image is the image the container will be based on.
+	Image string `json:"image"`
+	// Command is the container's command.
+	Command []string `json:"command,omitempty"`
+	// Entrypoint is the container's entrypoint.
+	Entrypoint []string `json:"entrypoint,omitempty"`
+	// Env is a set of environment variables that will be set in the
+	// container.
+	Env map[string]string `json:"env,omitempty"`
+	// Labels are key-value pairs that are used to add metadata to
+	// containers.
+	Labels map[string]string `json:"labels,omitempty"`
+	// WorkDir is the container's working directory.
+	WorkDir string `json:"work_dir,omitempty"`
+	// User is the user the container will be run as.
+	User string `json:"user,omitempty"`
+	// Terminal is whether the container will create a PTY.
+	Terminal bool `json:"terminal,omitempty"`
+	// Stdin is whether the container will keep its STDIN open.
+	Stdin bool `json:"stdin,omitempty"`
+	// Remove indicates if the container should be removed once it has
+	// been started and exits.
+	Remove bool `json:"remove,omitempty"`
+	// StopSignal is the signal that will be used to stop the container.
+	StopSignal *syscall.Signal `json:"stop_signal,omitempty"`
+	// StopTimeout is a timeout between the container's stop signal being
+	// sent and SIGKILL being sent.
+	StopTimeout *uint `json:"stop_timeout,omitempty"`
+	// RestartPolicy is the container's restart policy - an action which
+	// will be taken when the container exits.
+	RestartPolicy string `json:"restart_policy,omitempty"`
+	// RestartRetries is the number of attempts that will be made to
+	// restart the container.
+	RestartRetries *uint `json:"restart_tries,omitempty"`
+	// PortMappings is a set of ports to map into the container.
+	PortMappings []PortMappingSpec `json:"portmappings,omitempty"`
+	// Mounts are mounts that will be added to the container.
+	Mounts []Mount `json:"mounts,omitempty"`
+	// Volumes are named volumes that will be added to the container.
+	Volumes []*NamedVolume `json:"volumes,omitempty"`
+	// HealthConfig describes the container's healthcheck.
+	HealthConfig *HealthConfig `json:"healthconfig,omitempty"`
+	// NetNS is the configuration to use for the container's network
+	// namespace.
+	NetNS *Namespace `json:"netns,omitempty"`
+	// Networks is a map of networks names and ids the container should
+	// join to.
+	Networks map[string]PerNetworkOptions `json:"Networks,omitempty"`
+	// ResourceLimits are resource limits to apply to the container.
+	ResourceLimits *LinuxResources `json:"resource_limits,omitempty"`
+}
+
+// LinuxResources has container runtime resource constraints
+type LinuxResources struct {
+	// Memory restriction configuration
+	Memory *LinuxMemory `json:"memory,omitempty"`
+	// CPU resource restriction configuration
+	CPU *LinuxCPU `json:"cpu,omitempty"`
+	// Task resource restriction configuration.
+	Pids *LinuxPids `json:"pids,omitempty"`
+}
+
+// LinuxMemory for Linux cgroup 'memory' resource management
+type LinuxMemory struct {
+	// Memory limit (in bytes).
+	Limit *int64 `json:"limit,omitempty"`
+	// Memory reservation or soft_limit (in bytes).
+	Reservation *int64 `json:"reservation,omitempty"`
+	// Total memory limit (memory + swap).
+	Swap *int64 `json:"swap,omitempty"`
+}
+
+// LinuxCPU for Linux cgroup 'cpu' resource management
+type LinuxCPU struct {
+	// CPU shares (relative weight (ratio) vs. other cgroups with cpu shares).
+	Shares *uint64 `json:"shares,omitempty"`
+	// CPU hardcap limit (in usecs). Allowed cpu time in a given period.
+	Quota *int64 `json:"quota,omitempty"`
+	// CPU period to be used for hardcapping (in usecs).
+	Period *uint64 `json:"period,omitempty"`
+	// CPUs to use within the cpuset. Default is to use any CPU available.
+	Cpus string `json:"cpus,omitempty"`
+}
+
+// LinuxPids for Linux cgroup 'pids' resource management (Linux 4.3)
+type LinuxPids struct {
+	// Maximum number of PIDs. Default is "no limit".
+	Limit int64 `json:"limit"`
+}
+
+// HealthConfig holds configuration settings for the HEALTHCHECK feature.
+// Durations are in nanoseconds.
+type HealthConfig struct {
+	// Test is the test to perform to check that the container is healthy.
+	// An empty slice means to inherit the default.
+	// The options are:
+	// {} : inherit healthcheck
+	// {"NONE"} : disable healthcheck
+	// {"CMD", args...} : exec arguments directly
+	// {"CMD-SHELL", command} : run command with system's default shell
+	Test []string `json:",omitempty"`
+
+	// Zero means to inherit. Durations are expressed as integer nanoseconds.
+	Interval      int64 `json:",omitempty"` // Interval is the time to wait between checks.
+	Timeout       int64 `json:",omitempty"` // Timeout is the time to wait before considering the check to have hung.
+	StartPeriod   int64 `json:",omitempty"` // StartPeriod is the time to wait for container initialization before starting health-retries countdown.
+	StartInterval int64 `json:",omitempty"` // StartInterval is the time to wait between checks during the start period.
+
+	// Retries is the number of consecutive failures needed to consider a container as unhealthy.
+	// Zero means inherit.
+	Retries int `json:",omitempty"`
+}
+
+// Namespace describes the namespace
+type Namespace struct {
+	NSMode string `json:"nsmode,omitempty"`
+	Value  string `json:"value,omitempty"`
+}
+
+// PerNetworkOptions are options which should be set on a per network basis.
+type PerNetworkOptions struct {
+	// StaticIPs for this container. Optional.
+	StaticIPs []string `json:"static_ips,omitempty"`
+	// Aliases contains a list of names which the dns server should resolve
+	// to this container. Should only be set when DNSEnabled is true on the Network.
+	Aliases []string `json:"aliases,omitempty"`
+	// InterfaceName for this container. Required in the backend.
+	// Optional in the frontend. Will be filled with ethX (where X is a integer) when empty.
+	InterfaceName string `json:"interface_name"`
+}
+
+// PortMappingSpec is one or more ports that will be mapped into the container.
+type PortMappingSpec struct {
+	// HostIP is the IP that we will bind to on the host.
+	HostIP string `json:"host_ip,omitempty"`
+	// ContainerPort is the port number that will be exposed from the
+	// container.
+	ContainerPort uint16 `json:"container_port"`
+	// HostPort is the port number that will be forwarded from the host into
+	// the container.
+	HostPort uint16 `json:"host_port,omitempty"`
+	// Range is the number of ports that will be forwarded, starting at
+	// HostPort and ContainerPort and counting up.
+	Range uint16 `json:"range,omitempty"`
+	// Protocol is the protocol forward.
+	Protocol string `json:"protocol,omitempty"`
+}
+
+// Mount specifies a mount for a container.
+type Mount struct {
+	// Destination is the absolute path where the mount will be placed in
+	// the container.
+	Destination string `json:"destination"`
+	// Type specifies the mount kind.
+	Type string `json:"type,omitempty"`
+	// Source specifies the source path of the mount.
+	Source string `json:"source,omitempty"`
+	// Options are fstab style mount options.
+	Options []string `json:"options,omitempty"`
+}
+
+// NamedVolume holds information about a named volume that will be mounted
+// into the container.
+type NamedVolume struct {
+	// Name is the name of the named volume to be mounted. May be empty.
+	// If empty, a new named volume with a pseudorandomly generated name
+	// will be mounted at the given destination.
+	Name string
+	// Destination to mount the named volume within the container. Must be
+	// an absolute path. Path will be created if it does not exist.
+	Dest string
+	// Options are options that the named volume will be mounted with.
+	Options []string
+}
+
+// ContainerCreateResponse is the response struct for creating a container
+type ContainerCreateResponse struct {
+	// ID of the container created
+	ID string `json:"Id"`
+	// Warnings during container creation
+	Warnings []string `json:"Warnings"`
+}
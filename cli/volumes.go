@@ -0,0 +1,130 @@
+package cli
+
+import (
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	jsoniter "github.com/json-iterator/go"
+)
+
+// VolumeCreateOptions provides details for creating volumes
+type VolumeCreateOptions struct {
+	// New volume's name. Can be left blank
+	Name string `json:"Name"`
+	// Volume driver to use
+	Driver string `json:"Driver"`
+	// User-defined key/value metadata.
+	Label map[string]string `json:"Label"`
+	// Mapping of driver options and values.
+	Options map[string]string `json:"Options"`
+}
+
+// VolumeConfigResponse describes a volume as returned by the libpod API
+type VolumeConfigResponse struct {
+	// Name is the name of the volume.
+	Name string `json:"Name"`
+	// Driver is the driver used to create the volume.
+	Driver string `json:"Driver"`
+	// Mountpoint is the path on the host where the volume is mounted.
+	Mountpoint string `json:"Mountpoint"`
+	// Labels includes the volume's configured labels, key:value pairs that
+	// can be passed during volume creation to provide information for third
+	// party tools.
+	Labels map[string]string `json:"Labels"`
+	// Options is a set of options that were used when creating the volume.
+	Options map[string]string `json:"Options"`
+}
+
+// CreateVolume creates a volume given its configuration.
+func CreateVolume(config VolumeCreateOptions) (*VolumeConfigResponse, error) {
+	var v VolumeConfigResponse
+	conn, err := GetClient(getConnection())
+	if err != nil {
+		return nil, err
+	}
+	createString, err := jsoniter.MarshalToString(config)
+	if err != nil {
+		return nil, err
+	}
+	response, err := conn.DoRequest(strings.NewReader(createString), http.MethodPost, "/volumes/create", nil)
+	if err != nil {
+		return nil, err
+	}
+	return &v, response.Process(&v)
+}
+
+// InspectVolume returns low-level information about a volume.
+func InspectVolume(nameOrID string) (*VolumeConfigResponse, error) {
+	var inspect VolumeConfigResponse
+	conn, err := GetClient(getConnection())
+	if err != nil {
+		return nil, err
+	}
+	response, err := conn.DoRequest(nil, http.MethodGet, "/volumes/%s/json", nil, nameOrID)
+	if err != nil {
+		return &inspect, err
+	}
+	return &inspect, response.Process(&inspect)
+}
+
+// VolumeExists returns true if a given volume exists
+func VolumeExists(nameOrID string) (bool, error) {
+	conn, err := GetClient(getConnection())
+	if err != nil {
+		return false, err
+	}
+	response, err := conn.DoRequest(nil, http.MethodGet, "/volumes/%s/exists", nil, nameOrID)
+	if err != nil {
+		return false, err
+	}
+	defer response.Body.Close()
+	if response.IsSuccess() {
+		return true, nil
+	}
+	if response.StatusCode == http.StatusNotFound {
+		return false, nil
+	}
+	return false, response.Process(nil)
+}
+
+// ListVolumes returns the configurations for existing volumes in the form of a slice.  Optionally, filters
+// can be used to refine the list of volumes.
+func ListVolumes(filters map[string][]string) ([]*VolumeConfigResponse, error) {
+	var vols []*VolumeConfigResponse
+	conn, err := GetClient(getConnection())
+	if err != nil {
+		return nil, err
+	}
+	params := url.Values{}
+	if len(filters) > 0 {
+		strFilters, err := FiltersToString(filters)
+		if err != nil {
+			return nil, err
+		}
+		params.Set("filters", strFilters)
+	}
+	response, err := conn.DoRequest(nil, http.MethodGet, "/volumes/json", params)
+	if err != nil {
+		return vols, err
+	}
+	return vols, response.Process(&vols)
+}
+
+// RemoveVolume removes a volumes by name or ID.
+func RemoveVolume(nameOrID string, force *bool) error {
+	conn, err := GetClient(getConnection())
+	if err != nil {
+		return err
+	}
+	params := url.Values{}
+	if force != nil {
+		params.Set("force", strconv.FormatBool(*force))
+	}
+	response, err := conn.DoRequest(nil, http.MethodDelete, "/volumes/%s", params, nameOrID)
+	if err != nil {
+		return err
+	}
+	return response.Process(nil)
+}
@@ -3,12 +3,59 @@ package compose
 import (
 	"crypto/md5"
 	"encoding/hex"
+	"errors"
+	"fmt"
+	"gopkg.in/yaml.v3"
 	"sort"
 	"strconv"
 	"strings"
 )
 
-func (config *ServiceConfig) GetUnique() string {
+/*
+*
+旧版本(没有哈希版本标签)的服务配置，字段和类型与旧版本保持一致
+只用于计算旧的配置 key，不要修改
+*/
+type legacyServiceConfig struct {
+	Image      string `yaml:"image"`
+	Restart    string `yaml:"restart,omitempty"`
+	Entrypoint string `yaml:"entrypoint,omitempty"`
+	WorkingDir string `yaml:"working_dir,omitempty"`
+	Deploy     struct {
+		Limits struct {
+			CPUs   float64 `yaml:"cpus,omitempty"`
+			Memory string  `yaml:"memory,omitempty"`
+		} `yaml:"limits,omitempty"`
+	} `yaml:"resources,omitempty"`
+	ContainerName string   `yaml:"container_name,omitempty"`
+	Command       []string `yaml:"command,omitempty"`
+	Ports         []string `yaml:"ports,omitempty"`
+	Environment   any      `yaml:"environment,omitempty"`
+	Volumes       []string `yaml:"volumes,omitempty"`
+}
+
+/*
+*
+按旧版本的方式解析合并后的配置，计算每个服务的旧配置 key
+旧版本忽略解析错误，这里同样忽略
+*/
+func legacyConfigKeys(merged map[string]any) map[string]string {
+	var legacy struct {
+		Services map[string]legacyServiceConfig `yaml:"services"`
+	}
+	data, err := yaml.Marshal(merged)
+	if err != nil {
+		return nil
+	}
+	yaml.Unmarshal(data, &legacy)
+	keys := make(map[string]string, len(legacy.Services))
+	for name, service := range legacy.Services {
+		keys[name] = service.getUnique()
+	}
+	return keys
+}
+
+func (config *legacyServiceConfig) getUnique() string {
 
 	key1 := config.toBinary()
 	key2 := key1 + "-key2"
@@ -22,7 +69,7 @@ func (config *ServiceConfig) GetUnique() string {
 	return md51 + "-" + md52
 }
 
-func (config *ServiceConfig) toBinary() string {
+func (config *legacyServiceConfig) toBinary() string {
 	var rs []string
 	rs = append(rs, config.Image, config.Restart, config.Entrypoint,
 		config.WorkingDir,
@@ -33,8 +80,8 @@ func (config *ServiceConfig) toBinary() string {
 	rs = append(rs, config.Ports...)
 
 	var envKeys []string
-	mapEnv, _ := config.GetEnvironment()
-	for k, _ := range mapEnv {
+	mapEnv, _ := config.getEnvironment()
+	for k := range mapEnv {
 		envKeys = append(envKeys, k)
 	}
 
@@ -46,3 +93,34 @@ func (config *ServiceConfig) toBinary() string {
 	rs = append(rs, config.Volumes...)
 	return strings.Join(rs, "-")
 }
+
+func (config *legacyServiceConfig) getEnvironment() (map[string]string, error) {
+	if config.Environment == nil {
+		return nil, nil
+	}
+	result := make(map[string]string)
+	envMap, ok := config.Environment.(map[string]any)
+	if ok {
+		for key, val := range envMap {
+			result[fmt.Sprintf("%v", key)] = fmt.Sprintf("%v", val)
+		}
+		return result, nil
+	}
+
+	list, ok := config.Environment.([]interface{})
+	if ok {
+		for _, item := range list {
+			kvString, ok := item.(string)
+			if !ok {
+				return nil, errors.New("environment format error")
+			}
+			idx := strings.IndexByte(kvString, '=')
+			if idx == -1 {
+				return nil, errors.New("environment format error")
+			}
+			result[kvString[:idx]] = kvString[idx+1:]
+		}
+		return result, nil
+	}
+	return nil, errors.New("environment format error")
+}
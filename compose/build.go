@@ -0,0 +1,141 @@
+package compose
+
+import (
+	"fmt"
+	"gopkg.in/yaml.v3"
+	"strings"
+)
+
+// BuildConfig 定义了服务的构建配置
+type BuildConfig struct {
+	Context    string   `yaml:"context,omitempty"`
+	Dockerfile string   `yaml:"dockerfile,omitempty"`
+	Args       any      `yaml:"args,omitempty"`
+	Target     string   `yaml:"target,omitempty"`
+	Labels     any      `yaml:"labels,omitempty"`
+	CacheFrom  []string `yaml:"cache_from,omitempty"`
+	Network    string   `yaml:"network,omitempty"`
+	Secrets    []any    `yaml:"secrets,omitempty"`
+}
+
+// UnmarshalYAML build 可以只写构建目录
+func (c *BuildConfig) UnmarshalYAML(value *yaml.Node) error {
+	if value.Kind == yaml.ScalarNode {
+		c.Context = value.Value
+		return nil
+	}
+	type plain BuildConfig
+	return value.Decode((*plain)(c))
+}
+
+// SecretConfig 定义了顶层 secrets 中的密钥，顶层 configs 格式相同
+type SecretConfig struct {
+	Name        string `yaml:"name,omitempty"`
+	File        string `yaml:"file,omitempty"`
+	Environment string `yaml:"environment,omitempty"`
+	Content     string `yaml:"content,omitempty"`
+	External    any    `yaml:"external,omitempty"`
+}
+
+// BuildSecret 构建时使用的密钥
+type BuildSecret struct {
+	ID   string
+	File string
+}
+
+// GetArgs 构建参数，只写了参数名时从环境变量中取值
+func (c *BuildConfig) GetArgs() (map[string]string, error) {
+	args, err := ToStringMap(c.Args, "args")
+	if err != nil {
+		return nil, err
+	}
+	if list, ok := c.Args.([]any); ok {
+		for _, item := range list {
+			if name, ok := item.(string); ok && !strings.Contains(name, "=") {
+				if v, ok := lookupEnv(name); ok {
+					args[name] = v
+				} else {
+					delete(args, name)
+				}
+			}
+		}
+	}
+	return args, nil
+}
+
+/*
+*
+构建时使用的密钥，只支持 file 类型
+secrets 支持 - id 和 - {source: id, target: name} 两种写法
+*/
+func (c *BuildConfig) GetSecrets() ([]BuildSecret, error) {
+	var result []BuildSecret
+	for _, item := range c.Secrets {
+		var source, target string
+		switch v := item.(type) {
+		case string:
+			source, target = v, v
+		case map[string]any:
+			source = fmt.Sprintf("%v", v["source"])
+			target = source
+			if t, ok := v["target"]; ok && t != nil {
+				target = fmt.Sprintf("%v", t)
+			}
+		default:
+			return nil, fmt.Errorf("build secrets \"%v\" format error", item)
+		}
+		secret, exist := dockerCompose.Secrets[source]
+		if !exist {
+			return nil, fmt.Errorf("build refers to undefined secret %s", source)
+		}
+		if secret.File == "" {
+			return nil, fmt.Errorf("secret %s: only file secrets are supported for build", source)
+		}
+		result = append(result, BuildSecret{ID: target, File: ResolvePath(secret.File)})
+	}
+	return result, nil
+}
+
+// GetImageName 服务使用的镜像，只有 build 时为 <project>-<service>
+func GetImageName(serviceName string, service ServiceConfig) string {
+	image := strings.TrimSpace(service.Image)
+	if image != "" {
+		return image
+	}
+	if service.Build != nil {
+		return projectName + "-" + serviceName
+	}
+	return ""
+}
+
+// 检查构建配置
+func checkBuild() error {
+	for serviceName, service := range dockerCompose.Services {
+		policy, _, err := service.GetPullPolicy()
+		if err != nil {
+			return fmt.Errorf("service %s: %v", serviceName, err)
+		}
+		if policy == PullPolicyBuild && service.Build == nil {
+			return fmt.Errorf("service %s has pull_policy build but no build context specified", serviceName)
+		}
+		if service.Build == nil {
+			if strings.TrimSpace(service.Image) == "" {
+				return fmt.Errorf("service %s has neither an image nor a build context specified", serviceName)
+			}
+			continue
+		}
+		_, err = service.Build.GetArgs()
+		if err != nil {
+			return fmt.Errorf("service %s: %v", serviceName, err)
+		}
+		_, err = ToStringMap(service.Build.Labels, "labels")
+		if err != nil {
+			return fmt.Errorf("service %s: %v", serviceName, err)
+		}
+		_, err = service.Build.GetSecrets()
+		if err != nil {
+			return fmt.Errorf("service %s: %v", serviceName, err)
+		}
+	}
+	return nil
+}
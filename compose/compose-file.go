@@ -4,30 +4,103 @@ import (
 	"fmt"
 	"gopkg.in/yaml.v3"
 	"os"
+	"path/filepath"
 	"podman-compose/util"
+	"regexp"
+	"strconv"
 	"strings"
+	"time"
 )
 
-// ServiceResources 定义了服务资源的限制
+// ServiceResources 定义了服务资源的限制和预留
 type ServiceResources struct {
-	Limits struct {
-		CPUs   float64 `yaml:"cpus,omitempty"`
-		Memory string  `yaml:"memory,omitempty"`
-	} `yaml:"limits,omitempty"`
+	Limits       ResourceLimit `yaml:"limits,omitempty"`
+	Reservations ResourceLimit `yaml:"reservations,omitempty"`
+}
+
+// ResourceLimit 资源的数量，cpus 可以是数字或字符串，memory 可以是字节数或者 512m 这样的容量
+type ResourceLimit struct {
+	CPUs   any   `yaml:"cpus,omitempty"`
+	Memory any   `yaml:"memory,omitempty"`
+	Pids   int64 `yaml:"pids,omitempty"`
+}
+
+// DeployConfig 定义了服务的 deploy 配置
+type DeployConfig struct {
+	Replicas  *int             `yaml:"replicas,omitempty"`
+	Resources ServiceResources `yaml:"resources,omitempty"`
+	// 其它未单独建模的字段
+	Extra map[string]any `yaml:",inline"`
 }
 
 // ServiceConfig 定义了服务的配置
 type ServiceConfig struct {
-	Image         string           `yaml:"image"`
-	Restart       string           `yaml:"restart,omitempty"`
-	Entrypoint    string           `yaml:"entrypoint,omitempty"`
-	WorkingDir    string           `yaml:"working_dir,omitempty"`
-	Deploy        ServiceResources `yaml:"resources,omitempty"`
-	ContainerName string           `yaml:"container_name,omitempty"`
-	Command       []string         `yaml:"command,omitempty"`
-	Ports         []string         `yaml:"ports,omitempty"`
-	Environment   any              `yaml:"environment,omitempty"`
-	Volumes       []string         `yaml:"volumes,omitempty"`
+	Image      string       `yaml:"image"`
+	PullPolicy string       `yaml:"pull_policy,omitempty"`
+	Restart    string       `yaml:"restart,omitempty"`
+	Entrypoint ShellCommand `yaml:"entrypoint,omitempty"`
+	WorkingDir string       `yaml:"working_dir,omitempty"`
+	User       string       `yaml:"user,omitempty"`
+	// 兼容旧版本写在服务下的 resources，deploy.resources 优先
+	Resources       ServiceResources   `yaml:"resources,omitempty"`
+	MemLimit        any                `yaml:"mem_limit,omitempty"`
+	MemReservation  any                `yaml:"mem_reservation,omitempty"`
+	MemswapLimit    any                `yaml:"memswap_limit,omitempty"`
+	CPUs            any                `yaml:"cpus,omitempty"`
+	CPUShares       any                `yaml:"cpu_shares,omitempty"`
+	Cpuset          string             `yaml:"cpuset,omitempty"`
+	PidsLimit       any                `yaml:"pids_limit,omitempty"`
+	Deploy          *DeployConfig      `yaml:"deploy,omitempty"`
+	Scale           *int               `yaml:"scale,omitempty"`
+	ContainerName   string             `yaml:"container_name,omitempty"`
+	Command         ShellCommand       `yaml:"command,omitempty"`
+	Ports           []string           `yaml:"ports,omitempty"`
+	Environment     any                `yaml:"environment,omitempty"`
+	Volumes         []string           `yaml:"volumes,omitempty"`
+	DependsOn       any                `yaml:"depends_on,omitempty"`
+	StopGracePeriod string             `yaml:"stop_grace_period,omitempty"`
+	StopSignal      string             `yaml:"stop_signal,omitempty"`
+	Networks        any                `yaml:"networks,omitempty"`
+	NetworkMode     string             `yaml:"network_mode,omitempty"`
+	Healthcheck     *HealthcheckConfig `yaml:"healthcheck,omitempty"`
+	Build           *BuildConfig       `yaml:"build,omitempty"`
+	EnvFile         any                `yaml:"env_file,omitempty"`
+	Configs         []any              `yaml:"configs,omitempty"`
+	Secrets         []any              `yaml:"secrets,omitempty"`
+	// 其它未单独建模的字段，保留下来用于输出配置以及计算配置哈希
+	Extra map[string]any `yaml:",inline"`
+	// 旧版本算法计算的配置 key，加载配置文件时从原始配置计算
+	legacyKey string
+}
+
+// ShellCommand 命令，可以写成字符串或者列表，字符串按 shell 规则拆分
+type ShellCommand []string
+
+func (c *ShellCommand) UnmarshalYAML(value *yaml.Node) error {
+	if value.Kind == yaml.ScalarNode {
+		args, err := util.SplitCommand(value.Value)
+		if err != nil {
+			return err
+		}
+		*c = args
+		return nil
+	}
+	var args []string
+	err := value.Decode(&args)
+	*c = args
+	return err
+}
+
+// GetStopTimeout 停止容器时等待的秒数，默认 10 秒
+func (c *ServiceConfig) GetStopTimeout() (uint, error) {
+	if strings.TrimSpace(c.StopGracePeriod) == "" {
+		return 10, nil
+	}
+	duration, err := time.ParseDuration(strings.TrimSpace(c.StopGracePeriod))
+	if err != nil || duration < 0 {
+		return 0, fmt.Errorf("stop_grace_period \"%s\" format error", c.StopGracePeriod)
+	}
+	return uint(duration.Round(time.Second) / time.Second), nil
 }
 
 func (c *ServiceConfig) GetEnvironment() (map[string]string, error) {
@@ -38,7 +111,14 @@ func (c *ServiceConfig) GetEnvironment() (map[string]string, error) {
 	envMap, ok := c.Environment.(map[string]any)
 	if ok {
 		for key, val := range envMap {
-			result[fmt.Sprintf("%v", key)] = fmt.Sprintf("%v", val)
+			//只写了变量名时，从环境变量中取值
+			if val == nil {
+				if v, ok := lookupEnv(key); ok {
+					result[key] = v
+				}
+				continue
+			}
+			result[key] = fmt.Sprintf("%v", val)
 		}
 		return result, nil
 	}
@@ -48,11 +128,14 @@ func (c *ServiceConfig) GetEnvironment() (map[string]string, error) {
 		for _, item := range list {
 			kvString, ok := item.(string)
 			if !ok {
-				return nil, fmt.Errorf("environment \"", item, "\" format error")
+				return nil, fmt.Errorf("environment \"%v\" format error", item)
 			}
 			idx := strings.IndexByte(kvString, '=')
 			if idx == -1 {
-				return nil, fmt.Errorf("environment \"", kvString, "\" format error")
+				if v, ok := lookupEnv(kvString); ok {
+					result[kvString] = v
+				}
+				continue
 			}
 			result[kvString[:idx]] = kvString[idx+1:]
 		}
@@ -61,11 +144,89 @@ func (c *ServiceConfig) GetEnvironment() (map[string]string, error) {
 	return nil, fmt.Errorf("environment format error")
 }
 
+// EnvFile env_file 中的单个文件
+type EnvFile struct {
+	Path     string
+	Required bool
+}
+
+/*
+*
+解析 env_file，支持字符串、字符串列表以及 {path, required} 的长格式
+路径以项目目录为基准
+*/
+func (c *ServiceConfig) GetEnvFiles() ([]EnvFile, error) {
+	var items []any
+	switch v := c.EnvFile.(type) {
+	case nil:
+		return nil, nil
+	case string:
+		items = []any{v}
+	case []any:
+		items = v
+	default:
+		return nil, fmt.Errorf("env_file format error")
+	}
+	var result []EnvFile
+	for _, item := range items {
+		envFile := EnvFile{Required: true}
+		switch v := item.(type) {
+		case string:
+			envFile.Path = v
+		case map[string]any:
+			envFile.Path = fmt.Sprintf("%v", v["path"])
+			if required, ok := v["required"].(bool); ok {
+				envFile.Required = required
+			}
+		default:
+			return nil, fmt.Errorf("env_file \"%v\" format error", item)
+		}
+		envFile.Path = ResolvePath(envFile.Path)
+		result = append(result, envFile)
+	}
+	return result, nil
+}
+
+// ToStringMap key=value 列表或者 map 转换成 map
+func ToStringMap(value any, field string) (map[string]string, error) {
+	if value == nil {
+		return nil, nil
+	}
+	result := make(map[string]string)
+	switch v := value.(type) {
+	case map[string]any:
+		for key, val := range v {
+			if val == nil {
+				result[key] = ""
+			} else {
+				result[key] = fmt.Sprintf("%v", val)
+			}
+		}
+	case []any:
+		for _, item := range v {
+			kvString, ok := item.(string)
+			if !ok {
+				return nil, fmt.Errorf("%s \"%v\" format error", field, item)
+			}
+			key, val, _ := strings.Cut(kvString, "=")
+			result[key] = val
+		}
+	default:
+		return nil, fmt.Errorf("%s format error", field)
+	}
+	return result, nil
+}
+
 // DockerCompose 定义了整个docker-compose的配置
 type DockerCompose struct {
-	Version  string                   `yaml:"version"`
+	Version  string                   `yaml:"version,omitempty"`
+	Name     string                   `yaml:"name,omitempty"`
 	Services map[string]ServiceConfig `yaml:"services"`
-	Workdir  string
+	Volumes  map[string]VolumeConfig  `yaml:"volumes,omitempty"`
+	Networks map[string]NetworkConfig `yaml:"networks,omitempty"`
+	Secrets  map[string]SecretConfig  `yaml:"secrets,omitempty"`
+	Configs  map[string]SecretConfig  `yaml:"configs,omitempty"`
+	Workdir  string                   `yaml:"-"`
 }
 
 var dockerCompose DockerCompose
@@ -75,7 +236,11 @@ func GetDockerCompose() DockerCompose {
 	return dockerCompose
 }
 
+// GetComposeDir 项目目录，即配置文件所在的目录
 func GetComposeDir() string {
+	if dockerCompose.Workdir != "" {
+		return dockerCompose.Workdir
+	}
 	dir, err := os.Getwd()
 	if err != nil {
 		fmt.Println(err)
@@ -83,46 +248,242 @@ func GetComposeDir() string {
 	return dir
 }
 
+// ResolvePath 相对路径以项目目录为基准转换成绝对路径
+func ResolvePath(path string) string {
+	if path == "~" || strings.HasPrefix(path, "~/") {
+		home, err := os.UserHomeDir()
+		if err == nil {
+			return filepath.Join(home, path[1:])
+		}
+	}
+	if filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(GetComposeDir(), path)
+}
+
+// 挂载卷中的相对路径以项目目录为基准
+func resolveVolumePaths() {
+	for name, service := range dockerCompose.Services {
+		for i, volume := range service.Volumes {
+			parts := strings.SplitN(volume, ":", 2)
+			if len(parts) == 2 && (strings.HasPrefix(parts[0], ".") || strings.HasPrefix(parts[0], "~")) {
+				service.Volumes[i] = ResolvePath(parts[0]) + ":" + parts[1]
+			}
+		}
+		dockerCompose.Services[name] = service
+	}
+}
+
+// ProjectName 通过 -p 指定的项目名称
+var ProjectName string
+
+var projectName string
+
+// GetProjectName 获取项目名称
+func GetProjectName() string {
+	return projectName
+}
+
+/*
+*
+确定项目名称
+优先级: -p 参数 > COMPOSE_PROJECT_NAME 环境变量 > 配置文件中的 name > 目录名
+*/
+func initProjectName() error {
+	name := ProjectName
+	if name == "" {
+		name, _ = lookupEnv("COMPOSE_PROJECT_NAME")
+	}
+	if name == "" {
+		name = dockerCompose.Name
+	}
+	if name == "" {
+		name = normalizeProjectName(filepath.Base(GetComposeDir()))
+	}
+	if !projectNamePattern.MatchString(name) {
+		return fmt.Errorf("invalid project name %q: must consist only of lowercase alphanumeric characters, hyphens, and underscores as well as start with a letter or number", name)
+	}
+	projectName = name
+	dockerCompose.Name = name
+	return nil
+}
+
+var projectNamePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]*$`)
+
+// 目录名中不合法的字符去掉
+func normalizeProjectName(name string) string {
+	var builder strings.Builder
+	for _, c := range strings.ToLower(name) {
+		if (c >= 'a' && c <= 'z') || (c >= '0' && c <= '9') || c == '_' || c == '-' {
+			builder.WriteRune(c)
+		}
+	}
+	return strings.TrimLeft(builder.String(), "_-")
+}
+
+// GetContainerName 获取服务容器的名称 <project>-<service>-<n>
+func GetContainerName(serviceName string, service ServiceConfig, number int) string {
+	if strings.TrimSpace(service.ContainerName) != "" {
+		return service.ContainerName
+	}
+	return projectName + "-" + serviceName + "-" + strconv.Itoa(number)
+}
+
 var fixServiceNameSize = 10
 
 func FormatServiceName(name string) string {
 	return util.FixSizeString(name, fixServiceNameSize, false)
 }
 func InitCompose() error {
-	file, err := getComposeFile()
+	files, workdir, err := getComposeFiles()
+	if err != nil {
+		return err
+	}
+	err = loadProjectEnv(workdir)
+	if err != nil {
+		return err
+	}
+	err = loadComposeFiles(files)
+	if err != nil {
+		return err
+	}
+	dockerCompose.Workdir = workdir
+	resolveVolumePaths()
+	err = initProjectName()
 	if err != nil {
 		return err
 	}
-	yaml.NewDecoder(file).Decode(&dockerCompose)
 
 	for key := range dockerCompose.Services {
-		if len(key) >= fixServiceNameSize {
-			fixServiceNameSize = len(key) + 1
-		}
-
 		svr := dockerCompose.Services[key]
 		_, err = svr.GetEnvironment()
 		if err != nil {
 			return err
 		}
+		_, err = svr.GetEnvFiles()
+		if err != nil {
+			return fmt.Errorf("service %s: %v", key, err)
+		}
+		err = checkReplicas(key, svr)
+		if err != nil {
+			return err
+		}
+		_, err = svr.GetResources()
+		if err != nil {
+			return fmt.Errorf("service %s: %v", key, err)
+		}
+		err = svr.checkCPUReservation(key)
+		if err != nil {
+			return fmt.Errorf("service %s: %v", key, err)
+		}
+		_, err = svr.GetDependsOn()
+		if err != nil {
+			return fmt.Errorf("service %s: %v", key, err)
+		}
+		_, err = svr.GetStopTimeout()
+		if err != nil {
+			return fmt.Errorf("service %s: %v", key, err)
+		}
+		_, err = svr.GetStopSignal()
+		if err != nil {
+			return fmt.Errorf("service %s: %v", key, err)
+		}
+		_, err = svr.Healthcheck.GetHealthConfig()
+		if err != nil {
+			return fmt.Errorf("service %s: %v", key, err)
+		}
 	}
-	return nil
+	updateServiceNameSize()
+
+	for key, volume := range dockerCompose.Volumes {
+		_, err = ToStringMap(volume.Labels, "labels")
+		if err != nil {
+			return fmt.Errorf("volume %s: %v", key, err)
+		}
+	}
+
+	err = checkNetworks()
+	if err != nil {
+		return err
+	}
+	err = checkBuild()
+	if err != nil {
+		return err
+	}
+
+	//检查依赖关系是否存在循环
+	_, err = GetStartOrder(nil, true)
+	return err
 }
 
+// ComposeFiles 通过 -f 指定的配置文件
+var ComposeFiles []string
+
 var fileNames = []string{"docker-compose.yml", "docker-compose.yaml", "compose.yml", "compose.yaml"}
 
 /*
 *
-获取compose配置文件
+获取compose配置文件以及项目目录
+优先级: -f 参数 > COMPOSE_FILE 环境变量 > 当前目录或上级目录中的默认文件(以及对应的 override 文件)
+使用 -f 或 COMPOSE_FILE 时，项目目录为第一个文件所在的目录
 */
-func getComposeFile() (*os.File, error) {
-	for _, fileName := range fileNames {
-		_, err := os.Stat(fileName)
-		if err == nil {
-			return os.Open(fileName)
+func getComposeFiles() ([]string, string, error) {
+	files := ComposeFiles
+	if len(files) == 0 {
+		if v, found := os.LookupEnv("COMPOSE_FILE"); found && v != "" {
+			separator := string(os.PathListSeparator)
+			if s, found := os.LookupEnv("COMPOSE_PATH_SEPARATOR"); found && s != "" {
+				separator = s
+			}
+			files = strings.Split(v, separator)
+		}
+	}
+	if len(files) > 0 {
+		workdir, err := os.Getwd()
+		if err != nil {
+			return nil, "", err
+		}
+		if files[0] != "-" {
+			first, err := filepath.Abs(files[0])
+			if err != nil {
+				return nil, "", err
+			}
+			workdir = filepath.Dir(first)
+		}
+		return files, workdir, nil
+	}
+
+	dir, err := os.Getwd()
+	if err != nil {
+		return nil, "", err
+	}
+	//从当前目录开始逐级向上查找
+	for {
+		for _, fileName := range fileNames {
+			fileName = filepath.Join(dir, fileName)
+			_, err := os.Stat(fileName)
+			if err == nil {
+				files := []string{fileName}
+				//自动加载 override 文件
+				base := strings.TrimSuffix(fileName, filepath.Ext(fileName))
+				for _, ext := range []string{".yml", ".yaml"} {
+					overrideFile := base + ".override" + ext
+					if _, err = os.Stat(overrideFile); err == nil {
+						files = append(files, overrideFile)
+						break
+					}
+				}
+				return files, dir, nil
+			}
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			break
 		}
+		dir = parent
 	}
-	return nil, fmt.Errorf(`ERROR: 
+	return nil, "", fmt.Errorf(`ERROR: 
         Can't find a suitable configuration file in this directory or any
         parent. Are you in the right directory?
 
@@ -0,0 +1,27 @@
+package compose
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// 把配置写入临时目录并加载，env 为变量替换使用的环境变量
+func loadTestCompose(t *testing.T, content string, env map[string]string) {
+	t.Helper()
+	file := filepath.Join(t.TempDir(), "compose.yml")
+	err := os.WriteFile(file, []byte(content), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	projectEnv = env
+	if projectEnv == nil {
+		projectEnv = map[string]string{}
+	}
+	projectName = "test"
+	err = loadComposeFiles([]string{file})
+	if err != nil {
+		t.Fatal(err)
+	}
+	dockerCompose.Workdir = filepath.Dir(file)
+}
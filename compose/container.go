@@ -5,30 +5,138 @@ import (
 	"os"
 	"podman-compose/cli"
 	"podman-compose/constant"
+	"sort"
+	"strconv"
 	"sync"
 )
 
 var ContainerList []cli.ListContainer
 var lock sync.Mutex
 
+// GetContainer 服务序号最小的容器
 func GetContainer(serviceName string) (cli.ListContainer, bool) {
+	containers := GetContainers(serviceName)
+	if len(containers) == 0 {
+		return cli.ListContainer{}, false
+	}
+	return containers[0], true
+}
+
+// GetContainers 服务的所有容器，按序号排序，不包括 run 创建的一次性容器
+func GetContainers(serviceName string) []cli.ListContainer {
 	InitContainerList()
 
+	var containers []cli.ListContainer
 	for _, container := range ContainerList {
 		v, ok := container.Labels[constant.LabelComposeServiceName]
-		if ok && v == serviceName {
+		if ok && v == serviceName && !IsOneOff(container) {
+			containers = append(containers, container)
+		}
+	}
+	sort.SliceStable(containers, func(i, j int) bool {
+		return ContainerNumber(containers[i]) < ContainerNumber(containers[j])
+	})
+	return containers
+}
+
+// GetOneOffContainers 服务中由 run 创建的一次性容器
+func GetOneOffContainers(serviceName string) []cli.ListContainer {
+	InitContainerList()
+
+	var containers []cli.ListContainer
+	for _, container := range ContainerList {
+		v, ok := container.Labels[constant.LabelComposeServiceName]
+		if ok && v == serviceName && IsOneOff(container) {
+			containers = append(containers, container)
+		}
+	}
+	return containers
+}
+
+// IsOneOff 是否是 run 创建的一次性容器
+func IsOneOff(container cli.ListContainer) bool {
+	return container.Labels[constant.LabelOneOff] == "true"
+}
+
+// GetContainerByNumber 服务中指定序号的容器
+func GetContainerByNumber(serviceName string, number int) (cli.ListContainer, bool) {
+	for _, container := range GetContainers(serviceName) {
+		if ContainerNumber(container) == number {
 			return container, true
 		}
 	}
 	return cli.ListContainer{}, false
 }
 
+// ContainerNumber 容器的序号，旧版本创建的容器没有序号标签，视为 1
+func ContainerNumber(container cli.ListContainer) int {
+	number, err := strconv.Atoi(container.Labels[constant.LabelContainerNumber])
+	if err != nil {
+		return 1
+	}
+	return number
+}
+
+// ContainersUsing 使用指定资源的所有容器，包括其它项目的容器，filter 为 volume、network 或 ancestor
+func ContainersUsing(filter, value string) ([]cli.ListContainer, error) {
+	all := true
+	return cli.List(map[string][]string{filter: {value}}, &all, nil, nil, nil, nil)
+}
+
+/*
+*
+直接查询服务当前的容器，不使用也不修改缓存的容器列表
+用于并发创建容器时获取刚刚创建的依赖容器
+*/
+func lookupServiceContainer(serviceName string) (cli.ListContainer, bool, error) {
+	all := true
+	containers, err := cli.List(map[string][]string{
+		"label": {
+			constant.LabelComposeProject + "=" + projectName,
+			constant.LabelComposeServiceName + "=" + serviceName,
+		},
+	}, &all, nil, nil, nil, nil)
+	if err != nil {
+		return cli.ListContainer{}, false, err
+	}
+	var result cli.ListContainer
+	found := false
+	for _, container := range containers {
+		if IsOneOff(container) {
+			continue
+		}
+		if !found || ContainerNumber(container) < ContainerNumber(result) {
+			result = container
+			found = true
+		}
+	}
+	return result, found, nil
+}
+
+// RefreshContainerList 丢弃缓存，重新获取容器列表
+func RefreshContainerList() {
+	lock.Lock()
+	ContainerList = nil
+	lock.Unlock()
+	InitContainerList()
+}
+
+// IsProjectContainer 是否属于当前项目，兼容只有 compose-dir 标签的旧容器
+func IsProjectContainer(container cli.ListContainer, workDir string) bool {
+	project, ok := container.Labels[constant.LabelComposeProject]
+	if ok {
+		return project == projectName
+	}
+	dir, ok := container.Labels[constant.LabelComposeDir]
+	return ok && dir == workDir
+}
+
 /*
 *
 初始化容器列表
 */
 func InitContainerList() {
-	workDir, _ := os.Getwd()
+	workDir := GetComposeDir()
 
 	if ContainerList == nil {
 		lock.Lock()
@@ -41,11 +149,8 @@ func InitContainerList() {
 				os.Exit(1)
 			}
 			for _, c := range cs {
-				v, ok := c.Labels[constant.LabelComposeDir]
-				if ok {
-					if v == workDir {
-						containerListTmp = append(containerListTmp, c)
-					}
+				if IsProjectContainer(c, workDir) {
+					containerListTmp = append(containerListTmp, c)
 				}
 			}
 			ContainerList = containerListTmp
@@ -0,0 +1,189 @@
+package compose
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+const (
+	ConditionServiceStarted               = "service_started"
+	ConditionServiceHealthy               = "service_healthy"
+	ConditionServiceCompletedSuccessfully = "service_completed_successfully"
+)
+
+// ServiceDependency 定义了 depends_on 中单个依赖的配置
+type ServiceDependency struct {
+	Condition string
+	Restart   bool
+	Required  bool
+}
+
+/*
+*
+解析 depends_on，支持短格式(列表)和长格式(map)
+network_mode: service:X 使用 X 容器的网络，X 作为隐式依赖加入
+*/
+func (c *ServiceConfig) GetDependsOn() (map[string]ServiceDependency, error) {
+	result, err := c.getDeclaredDependsOn()
+	if err != nil {
+		return nil, err
+	}
+	mode, service, _ := strings.Cut(strings.TrimSpace(c.NetworkMode), ":")
+	if mode == "service" && service != "" {
+		if _, exist := result[service]; !exist {
+			if result == nil {
+				result = make(map[string]ServiceDependency)
+			}
+			result[service] = ServiceDependency{Condition: ConditionServiceStarted, Required: true}
+		}
+	}
+	return result, nil
+}
+
+func (c *ServiceConfig) getDeclaredDependsOn() (map[string]ServiceDependency, error) {
+	if c.DependsOn == nil {
+		return nil, nil
+	}
+	result := make(map[string]ServiceDependency)
+
+	list, ok := c.DependsOn.([]interface{})
+	if ok {
+		for _, item := range list {
+			name, ok := item.(string)
+			if !ok {
+				return nil, fmt.Errorf("depends_on \"%v\" format error", item)
+			}
+			result[name] = ServiceDependency{Condition: ConditionServiceStarted, Required: true}
+		}
+		return result, nil
+	}
+
+	depMap, ok := c.DependsOn.(map[string]any)
+	if ok {
+		for name, val := range depMap {
+			dep := ServiceDependency{Condition: ConditionServiceStarted, Required: true}
+			if val != nil {
+				options, ok := val.(map[string]any)
+				if !ok {
+					return nil, fmt.Errorf("depends_on \"%s\" format error", name)
+				}
+				if condition, ok := options["condition"]; ok {
+					dep.Condition = fmt.Sprintf("%v", condition)
+				}
+				if restart, ok := options["restart"].(bool); ok {
+					dep.Restart = restart
+				}
+				if required, ok := options["required"].(bool); ok {
+					dep.Required = required
+				}
+			}
+			switch dep.Condition {
+			case ConditionServiceStarted, ConditionServiceHealthy, ConditionServiceCompletedSuccessfully:
+			default:
+				return nil, fmt.Errorf("depends_on \"%s\" condition \"%s\" is invalid", name, dep.Condition)
+			}
+			result[name] = dep
+		}
+		return result, nil
+	}
+	return nil, fmt.Errorf("depends_on format error")
+}
+
+/*
+*
+按依赖关系获取服务的启动顺序，每一层内的服务互不依赖，可以同时启动
+names 为空时表示全部服务；withDeps 为 true 时会把依赖的服务一起加入
+*/
+func GetStartOrder(names []string, withDeps bool) ([][]string, error) {
+	services := dockerCompose.Services
+	if len(names) == 0 {
+		for name := range services {
+			names = append(names, name)
+		}
+	}
+
+	//待排序的服务集合
+	selected := map[string]bool{}
+	var collect func(name string) error
+	collect = func(name string) error {
+		if selected[name] {
+			return nil
+		}
+		service, exist := services[name]
+		if !exist {
+			return fmt.Errorf("Service %s does not exist", name)
+		}
+		selected[name] = true
+		if !withDeps {
+			return nil
+		}
+		deps, err := service.GetDependsOn()
+		if err != nil {
+			return err
+		}
+		for dep := range deps {
+			if _, exist := services[dep]; !exist {
+				if deps[dep].Required {
+					return fmt.Errorf("service %s depends on undefined service %s", name, dep)
+				}
+				continue
+			}
+			if err = collect(dep); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	for _, name := range names {
+		if err := collect(name); err != nil {
+			return nil, err
+		}
+	}
+
+	//计算入度
+	inDegree := map[string]int{}
+	dependents := map[string][]string{}
+	for name := range selected {
+		service := services[name]
+		deps, err := service.GetDependsOn()
+		if err != nil {
+			return nil, err
+		}
+		inDegree[name] += 0
+		for dep := range deps {
+			if !selected[dep] {
+				continue
+			}
+			inDegree[name]++
+			dependents[dep] = append(dependents[dep], name)
+		}
+	}
+
+	var levels [][]string
+	for len(inDegree) > 0 {
+		var level []string
+		for name, degree := range inDegree {
+			if degree == 0 {
+				level = append(level, name)
+			}
+		}
+		if len(level) == 0 {
+			var cycle []string
+			for name := range inDegree {
+				cycle = append(cycle, name)
+			}
+			sort.Strings(cycle)
+			return nil, fmt.Errorf("dependency cycle detected between services: %s", strings.Join(cycle, ", "))
+		}
+		sort.Strings(level)
+		for _, name := range level {
+			delete(inDegree, name)
+			for _, dependent := range dependents[name] {
+				inDegree[dependent]--
+			}
+		}
+		levels = append(levels, level)
+	}
+	return levels, nil
+}
@@ -0,0 +1,138 @@
+package compose
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestGetDependsOn(t *testing.T) {
+	loadTestCompose(t, `
+services:
+  short:
+    image: a
+    depends_on: [db, cache]
+  long:
+    image: a
+    depends_on:
+      db:
+        condition: service_healthy
+        restart: true
+      cache:
+        condition: service_completed_successfully
+        required: false
+  sidecar:
+    image: a
+    network_mode: service:db
+`, nil)
+
+	tests := []struct {
+		service string
+		want    map[string]ServiceDependency
+	}{
+		{"short", map[string]ServiceDependency{
+			"db":    {Condition: ConditionServiceStarted, Required: true},
+			"cache": {Condition: ConditionServiceStarted, Required: true},
+		}},
+		{"long", map[string]ServiceDependency{
+			"db":    {Condition: ConditionServiceHealthy, Restart: true, Required: true},
+			"cache": {Condition: ConditionServiceCompletedSuccessfully, Required: false},
+		}},
+		{"sidecar", map[string]ServiceDependency{
+			"db": {Condition: ConditionServiceStarted, Required: true},
+		}},
+	}
+	for _, tt := range tests {
+		service := dockerCompose.Services[tt.service]
+		got, err := service.GetDependsOn()
+		if err != nil {
+			t.Fatalf("%s: %v", tt.service, err)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: got %v, want %v", tt.service, got, tt.want)
+		}
+	}
+}
+
+func TestGetDependsOnInvalidCondition(t *testing.T) {
+	service := ServiceConfig{DependsOn: map[string]any{"db": map[string]any{"condition": "service_ready"}}}
+	_, err := service.GetDependsOn()
+	if err == nil || !strings.Contains(err.Error(), "service_ready") {
+		t.Fatalf("expected invalid condition error, got %v", err)
+	}
+}
+
+func TestGetStartOrder(t *testing.T) {
+	loadTestCompose(t, `
+services:
+  web:
+    image: a
+    depends_on: [api, cache]
+  api:
+    image: a
+    depends_on: [db]
+  db:
+    image: a
+  cache:
+    image: a
+  worker:
+    image: a
+    depends_on:
+      missing:
+        condition: service_started
+        required: false
+`, nil)
+
+	tests := []struct {
+		names    []string
+		withDeps bool
+		want     [][]string
+	}{
+		{nil, true, [][]string{{"cache", "db", "worker"}, {"api"}, {"web"}}},
+		{[]string{"web"}, true, [][]string{{"cache", "db"}, {"api"}, {"web"}}},
+		{[]string{"web"}, false, [][]string{{"web"}}},
+		{[]string{"web", "api"}, false, [][]string{{"api"}, {"web"}}},
+		{[]string{"worker"}, true, [][]string{{"worker"}}},
+	}
+	for _, tt := range tests {
+		got, err := GetStartOrder(tt.names, tt.withDeps)
+		if err != nil {
+			t.Fatalf("%v: %v", tt.names, err)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%v withDeps=%v: got %v, want %v", tt.names, tt.withDeps, got, tt.want)
+		}
+	}
+}
+
+func TestGetStartOrderErrors(t *testing.T) {
+	tests := []struct {
+		content string
+		want    string
+	}{
+		{`
+services:
+  a:
+    image: a
+    depends_on: [b]
+  b:
+    image: a
+    depends_on: [a]
+  c:
+    image: a
+`, "dependency cycle detected between services: a, b"},
+		{`
+services:
+  a:
+    image: a
+    depends_on: [b]
+`, "service a depends on undefined service b"},
+	}
+	for _, tt := range tests {
+		loadTestCompose(t, tt.content, nil)
+		_, err := GetStartOrder(nil, true)
+		if err == nil || err.Error() != tt.want {
+			t.Errorf("got %v, want %q", err, tt.want)
+		}
+	}
+}
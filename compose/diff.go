@@ -0,0 +1,78 @@
+package compose
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"sort"
+)
+
+/*
+*
+规范化后的服务配置中每个字段的哈希，以 json 保存在容器标签中，用于之后比较具体修改了哪些字段
+嵌套的 map 逐层展开，例如 environment.FOO；只保存哈希，不保存配置的明文(环境变量、密钥等)
+*/
+func (c *ServiceConfig) GetFieldHashes() (string, error) {
+	canonical, err := c.CanonicalConfig()
+	if err != nil {
+		return "", err
+	}
+	hashes := map[string]string{}
+	err = hashFields("", canonical, hashes)
+	if err != nil {
+		return "", err
+	}
+	data, err := json.Marshal(hashes)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+func hashFields(prefix string, config map[string]any, hashes map[string]string) error {
+	for key, value := range config {
+		if child, ok := value.(map[string]any); ok && len(child) > 0 {
+			err := hashFields(prefix+key+".", child, hashes)
+			if err != nil {
+				return err
+			}
+			continue
+		}
+		data, err := json.Marshal(value)
+		if err != nil {
+			return err
+		}
+		sum := sha256.Sum256(data)
+		hashes[prefix+key] = hex.EncodeToString(sum[:])
+	}
+	return nil
+}
+
+/*
+*
+比较两份字段哈希，返回修改过的字段
+*/
+func DiffFieldHashes(oldJson, newJson string) ([]string, error) {
+	var oldHashes, newHashes map[string]string
+	err := json.Unmarshal([]byte(oldJson), &oldHashes)
+	if err != nil {
+		return nil, err
+	}
+	err = json.Unmarshal([]byte(newJson), &newHashes)
+	if err != nil {
+		return nil, err
+	}
+	var changes []string
+	for key, hash := range oldHashes {
+		if newHashes[key] != hash {
+			changes = append(changes, key)
+		}
+	}
+	for key := range newHashes {
+		if _, exist := oldHashes[key]; !exist {
+			changes = append(changes, key)
+		}
+	}
+	sort.Strings(changes)
+	return changes, nil
+}
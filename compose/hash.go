@@ -0,0 +1,157 @@
+package compose
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"gopkg.in/yaml.v3"
+	"os"
+	"podman-compose/constant"
+)
+
+// ConfigHashVersion 配置哈希算法的版本，算法变化时递增
+// 旧版本创建的容器按创建时的算法比较，升级后不会因为算法变化而全部重建
+const ConfigHashVersion = "2"
+
+// 不影响容器本身的字段不参与哈希，镜像的变化通过镜像 ID 判断，副本数的变化只增删容器
+var hashIgnoredKeys = []string{"build", "pull_policy", "scale"}
+
+/*
+*
+计算服务配置的哈希
+对规范化后的完整服务配置(包括 env_file 的内容以及引用的 configs、secrets 内容摘要)做规范序列化后计算 sha256
+*/
+func (c *ServiceConfig) GetConfigHash() (string, error) {
+	canonical, err := c.CanonicalConfig()
+	if err != nil {
+		return "", err
+	}
+	data, err := json.Marshal(canonical)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+/*
+*
+规范化后的完整服务配置
+env_file、configs、secrets 的内容摘要放在 x-content-digests 中
+json 序列化时 map 的 key 是有序的，因此序列化结果是稳定的
+*/
+func (c *ServiceConfig) CanonicalConfig() (map[string]any, error) {
+	normalized, err := c.Normalize()
+	if err != nil {
+		return nil, err
+	}
+	data, err := yaml.Marshal(normalized)
+	if err != nil {
+		return nil, err
+	}
+	content := map[string]any{}
+	err = yaml.Unmarshal(data, &content)
+	if err != nil {
+		return nil, err
+	}
+	for _, key := range hashIgnoredKeys {
+		delete(content, key)
+	}
+	if deploy, ok := content["deploy"].(map[string]any); ok {
+		delete(deploy, "replicas")
+		if len(deploy) == 0 {
+			delete(content, "deploy")
+		}
+	}
+
+	digests := map[string]any{}
+	for kind, refs := range map[string][]any{"configs": c.Configs, "secrets": c.Secrets} {
+		definitions := dockerCompose.Configs
+		if kind == "secrets" {
+			definitions = dockerCompose.Secrets
+		}
+		for _, ref := range refs {
+			name := referenceSource(ref)
+			definition, exist := definitions[name]
+			if !exist {
+				return nil, fmt.Errorf("%s %s is not defined", kind, name)
+			}
+			digest, err := contentDigest(definition)
+			if err != nil {
+				return nil, fmt.Errorf("%s %s: %v", kind, name, err)
+			}
+			digests[kind+"/"+name] = digest
+		}
+	}
+	envFiles, err := c.GetEnvFiles()
+	if err != nil {
+		return nil, err
+	}
+	for _, envFile := range envFiles {
+		data, err := os.ReadFile(envFile.Path)
+		if err != nil {
+			if !envFile.Required && os.IsNotExist(err) {
+				continue
+			}
+			return nil, fmt.Errorf("env_file %s: %v", envFile.Path, err)
+		}
+		sum := sha256.Sum256(data)
+		digests["env_file/"+envFile.Path] = hex.EncodeToString(sum[:])
+	}
+	if len(digests) > 0 {
+		content["x-content-digests"] = digests
+	}
+	return content, nil
+}
+
+// configs、secrets 的引用，短格式为名称，长格式的 source 为名称
+func referenceSource(ref any) string {
+	if v, ok := ref.(map[string]any); ok {
+		return fmt.Sprintf("%v", v["source"])
+	}
+	return fmt.Sprintf("%v", ref)
+}
+
+// configs、secrets 内容的摘要
+func contentDigest(definition SecretConfig) (string, error) {
+	var content []byte
+	switch {
+	case definition.File != "":
+		data, err := os.ReadFile(ResolvePath(definition.File))
+		if err != nil {
+			return "", err
+		}
+		content = data
+	case definition.Environment != "":
+		value, _ := lookupEnv(definition.Environment)
+		content = []byte(value)
+	case definition.Content != "":
+		content = []byte(definition.Content)
+	default:
+		//外部的配置无法读取内容，只按名称区分
+		content = []byte("external:" + definition.Name)
+	}
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+/*
+*
+容器的配置是否与当前服务配置一致
+没有哈希版本标签的容器由旧版本创建，按旧的算法比较
+*/
+func (c *ServiceConfig) MatchConfigKey(labels map[string]string) (bool, error) {
+	key := labels[constant.LabelConfigKey]
+	switch labels[constant.LabelConfigHashVersion] {
+	case "":
+		return c.legacyKey != "" && key == c.legacyKey, nil
+	case ConfigHashVersion:
+		hash, err := c.GetConfigHash()
+		if err != nil {
+			return false, err
+		}
+		return key == hash, nil
+	}
+	return false, nil
+}
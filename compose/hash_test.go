@@ -0,0 +1,268 @@
+package compose
+
+import (
+	"os"
+	"path/filepath"
+	"podman-compose/constant"
+	"reflect"
+	"testing"
+)
+
+// 加载配置并计算 web 服务的配置哈希
+func webConfigHash(t *testing.T, content string) string {
+	t.Helper()
+	loadTestCompose(t, content, nil)
+	service := dockerCompose.Services["web"]
+	hash, err := service.GetConfigHash()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return hash
+}
+
+func TestConfigHashEquivalentConfigs(t *testing.T) {
+	tests := []struct {
+		name string
+		a    string
+		b    string
+	}{
+		{"key order", `
+services:
+  web:
+    image: nginx
+    restart: always
+    cap_add: [NET_ADMIN]
+`, `
+services:
+  web:
+    cap_add: [NET_ADMIN]
+    restart: always
+    image: nginx
+`},
+		{"environment list and map", `
+services:
+  web:
+    image: nginx
+    environment: [A=1, B=2]
+`, `
+services:
+  web:
+    image: nginx
+    environment:
+      B: "2"
+      A: "1"
+`},
+		{"ignored fields", `
+services:
+  web:
+    image: nginx
+`, `
+services:
+  web:
+    image: nginx
+    pull_policy: always
+    scale: 3
+    build: .
+    deploy:
+      replicas: 2
+`},
+		{"long and short port syntax", `
+services:
+  web:
+    image: nginx
+    ports: ["8080:80/tcp"]
+`, `
+services:
+  web:
+    image: nginx
+    ports:
+      - target: 80
+        published: 8080
+        protocol: tcp
+`},
+	}
+	for _, tt := range tests {
+		if a, b := webConfigHash(t, tt.a), webConfigHash(t, tt.b); a != b {
+			t.Errorf("%s: hashes differ", tt.name)
+		}
+	}
+}
+
+func TestConfigHashChangedConfigs(t *testing.T) {
+	base := `
+services:
+  web:
+    image: nginx
+    environment: [A=1]
+`
+	tests := []struct {
+		name    string
+		changed string
+	}{
+		{"environment", `
+services:
+  web:
+    image: nginx
+    environment: [A=2]
+`},
+		{"unmodeled field", `
+services:
+  web:
+    image: nginx
+    environment: [A=1]
+    cap_add: [NET_ADMIN]
+`},
+		{"deploy resources", `
+services:
+  web:
+    image: nginx
+    environment: [A=1]
+    deploy:
+      resources:
+        limits:
+          memory: 64m
+`},
+		{"healthcheck", `
+services:
+  web:
+    image: nginx
+    environment: [A=1]
+    healthcheck:
+      test: ["CMD", "true"]
+`},
+	}
+	baseHash := webConfigHash(t, base)
+	for _, tt := range tests {
+		if webConfigHash(t, tt.changed) == baseHash {
+			t.Errorf("%s: hash did not change", tt.name)
+		}
+	}
+}
+
+// env_file 和 configs 的内容变化时哈希也变化
+func TestConfigHashFileContents(t *testing.T) {
+	dir := t.TempDir()
+	envFile := filepath.Join(dir, "web.env")
+	configFile := filepath.Join(dir, "app.conf")
+	content := `
+services:
+  web:
+    image: nginx
+    env_file: ` + envFile + `
+    configs: [app]
+configs:
+  app:
+    file: ` + configFile + `
+`
+	write := func(file, data string) {
+		err := os.WriteFile(file, []byte(data), 0644)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	write(envFile, "A=1\n")
+	write(configFile, "one")
+	first := webConfigHash(t, content)
+
+	write(envFile, "A=2\n")
+	second := webConfigHash(t, content)
+	if second == first {
+		t.Errorf("env_file content change did not change the hash")
+	}
+
+	write(configFile, "two")
+	if webConfigHash(t, content) == second {
+		t.Errorf("config content change did not change the hash")
+	}
+}
+
+func TestMatchConfigKey(t *testing.T) {
+	hash := webConfigHash(t, `
+services:
+  web:
+    image: nginx
+`)
+	service := dockerCompose.Services["web"]
+	tests := []struct {
+		name   string
+		labels map[string]string
+		want   bool
+	}{
+		{"current version", map[string]string{constant.LabelConfigKey: hash, constant.LabelConfigHashVersion: ConfigHashVersion}, true},
+		{"changed", map[string]string{constant.LabelConfigKey: "other", constant.LabelConfigHashVersion: ConfigHashVersion}, false},
+		{"unknown version", map[string]string{constant.LabelConfigKey: hash, constant.LabelConfigHashVersion: "99"}, false},
+		{"legacy", map[string]string{constant.LabelConfigKey: service.legacyKey}, true},
+		{"legacy changed", map[string]string{constant.LabelConfigKey: "other"}, false},
+	}
+	for _, tt := range tests {
+		got, err := service.MatchConfigKey(tt.labels)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != tt.want {
+			t.Errorf("%s: got %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestDiffFieldHashes(t *testing.T) {
+	fieldHashes := func(content string) string {
+		loadTestCompose(t, content, nil)
+		service := dockerCompose.Services["web"]
+		hashes, err := service.GetFieldHashes()
+		if err != nil {
+			t.Fatal(err)
+		}
+		return hashes
+	}
+	old := fieldHashes(`
+services:
+  web:
+    image: nginx
+    environment:
+      FOO: "1"
+      BAR: "1"
+    restart: always
+`)
+	new := fieldHashes(`
+services:
+  web:
+    image: nginx
+    environment:
+      FOO: "2"
+      BAR: "1"
+    cap_add: [NET_ADMIN]
+`)
+	changes, err := DiffFieldHashes(old, new)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"cap_add", "environment.FOO", "restart"}
+	if !reflect.DeepEqual(changes, want) {
+		t.Errorf("got %v, want %v", changes, want)
+	}
+}
+
+// 旧版本创建的容器的配置 key，值由旧版本的算法计算，算法不能变化
+func TestLegacyConfigKey(t *testing.T) {
+	loadTestCompose(t, `
+services:
+  web:
+    image: nginx
+    entrypoint: sh -c "echo 'a b'"
+    resources:
+      limits:
+        cpus: 0.5
+        memory: 64m
+    command: ["a", "b"]
+    ports: ["80:80"]
+    environment:
+      A: 1
+      B: x
+    volumes: ["./data:/data"]
+`, nil)
+	want := "67df395807167ce69e30a5245057d226-1d971d556b0f6d6a6e96cc44ffb73010"
+	if got := dockerCompose.Services["web"].legacyKey; got != want {
+		t.Errorf("got %s, want %s", got, want)
+	}
+}
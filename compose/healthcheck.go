@@ -0,0 +1,106 @@
+package compose
+
+import (
+	"fmt"
+	"podman-compose/cli"
+	"strings"
+	"time"
+)
+
+// HealthcheckConfig 定义了服务的健康检查
+type HealthcheckConfig struct {
+	Test          any    `yaml:"test,omitempty"`
+	Interval      string `yaml:"interval,omitempty"`
+	Timeout       string `yaml:"timeout,omitempty"`
+	Retries       int    `yaml:"retries,omitempty"`
+	StartPeriod   string `yaml:"start_period,omitempty"`
+	StartInterval string `yaml:"start_interval,omitempty"`
+	Disable       bool   `yaml:"disable,omitempty"`
+}
+
+/*
+*
+转换成创建容器时的健康检查配置
+test 支持字符串(等同于 CMD-SHELL)以及 ["CMD", ...] ["CMD-SHELL", ...] ["NONE"] 三种列表写法
+*/
+func (c *HealthcheckConfig) GetHealthConfig() (*cli.HealthConfig, error) {
+	if c == nil {
+		return nil, nil
+	}
+	if c.Disable {
+		return &cli.HealthConfig{Test: []string{"NONE"}}, nil
+	}
+
+	config := &cli.HealthConfig{Retries: c.Retries}
+	switch test := c.Test.(type) {
+	case nil:
+	case string:
+		config.Test = []string{"CMD-SHELL", test}
+	case []any:
+		for _, item := range test {
+			config.Test = append(config.Test, fmt.Sprintf("%v", item))
+		}
+		if len(config.Test) > 0 {
+			switch config.Test[0] {
+			case "CMD", "CMD-SHELL", "NONE":
+			default:
+				return nil, fmt.Errorf("healthcheck test must start with CMD, CMD-SHELL or NONE")
+			}
+		}
+	default:
+		return nil, fmt.Errorf("healthcheck test format error")
+	}
+
+	var err error
+	for _, duration := range []struct {
+		name  string
+		value string
+		out   *int64
+	}{
+		{"interval", c.Interval, &config.Interval},
+		{"timeout", c.Timeout, &config.Timeout},
+		{"start_period", c.StartPeriod, &config.StartPeriod},
+		{"start_interval", c.StartInterval, &config.StartInterval},
+	} {
+		*duration.out, err = parseDuration(duration.name, duration.value)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return config, nil
+}
+
+func parseDuration(name string, value string) (int64, error) {
+	if strings.TrimSpace(value) == "" {
+		return 0, nil
+	}
+	duration, err := time.ParseDuration(strings.TrimSpace(value))
+	if err != nil {
+		return 0, fmt.Errorf("healthcheck %s \"%s\" format error", name, value)
+	}
+	return int64(duration), nil
+}
+
+/*
+*
+等待容器变为 healthy 的最长时间：start_period + (interval + timeout) * (retries + 1)，再留出一些余量
+没有配置的字段使用 podman 的默认值(interval 30s、timeout 30s、retries 3)
+*/
+func (c *HealthcheckConfig) GetHealthyTimeout() time.Duration {
+	interval, timeout, retries := 30*time.Second, 30*time.Second, 3
+	var startPeriod time.Duration
+	config, err := c.GetHealthConfig()
+	if err == nil && config != nil {
+		if config.Interval > 0 {
+			interval = time.Duration(config.Interval)
+		}
+		if config.Timeout > 0 {
+			timeout = time.Duration(config.Timeout)
+		}
+		if config.Retries > 0 {
+			retries = config.Retries
+		}
+		startPeriod = time.Duration(config.StartPeriod)
+	}
+	return startPeriod + (interval+timeout)*time.Duration(retries+1) + 30*time.Second
+}
@@ -0,0 +1,67 @@
+package compose
+
+import (
+	"fmt"
+	"podman-compose/cli"
+	"podman-compose/util"
+	"sort"
+	"strings"
+)
+
+// down --rmi 的取值
+const (
+	// RemoveImagesAll 删除服务使用的所有镜像
+	RemoveImagesAll = "all"
+	// RemoveImagesLocal 只删除没有通过 image 指定名称的镜像，即 build 生成的镜像
+	RemoveImagesLocal = "local"
+)
+
+/*
+*
+删除服务使用的镜像，还在被容器使用的镜像(包括其它项目的容器)不会删除
+返回删除的镜像
+*/
+func RemoveImages(serviceNames []string, mode string) ([]string, error) {
+	names := append([]string{}, serviceNames...)
+	sort.Strings(names)
+
+	seen := map[string]bool{}
+	var removed []string
+	for _, serviceName := range names {
+		service := dockerCompose.Services[serviceName]
+		if mode == RemoveImagesLocal && strings.TrimSpace(service.Image) != "" {
+			continue
+		}
+		image := GetImageName(serviceName, service)
+		if image == "" || seen[image] {
+			continue
+		}
+		seen[image] = true
+
+		exist, err := cli.ImageExists(image)
+		if err != nil {
+			return removed, err
+		}
+		if !exist {
+			continue
+		}
+		fmt.Print("Image " + image + " removing... ")
+		users, err := ContainersUsing("ancestor", image)
+		if err != nil {
+			fmt.Println(err)
+			continue
+		}
+		if len(users) > 0 {
+			fmt.Println(util.TextColor(33, "skipped") + " in use by " + users[0].Names[0])
+			continue
+		}
+		_, err = cli.RemoveImage(image, nil)
+		if err != nil {
+			fmt.Println(err)
+			continue
+		}
+		fmt.Println(util.TextColor(32, "done"))
+		removed = append(removed, image)
+	}
+	return removed, nil
+}
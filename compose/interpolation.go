@@ -0,0 +1,351 @@
+package compose
+
+import (
+	"bufio"
+	"fmt"
+	"gopkg.in/yaml.v3"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// EnvFiles 通过 --env-file 指定的环境变量文件
+var EnvFiles []string
+
+// 用于变量替换的环境变量，来自 .env 文件和进程的环境变量
+var projectEnv map[string]string
+
+/*
+*
+加载用于变量替换的环境变量
+进程的环境变量优先级高于 .env 文件
+*/
+func loadProjectEnv(dir string) error {
+	projectEnv = map[string]string{}
+	files := EnvFiles
+	if len(files) == 0 {
+		defaultFile := filepath.Join(dir, ".env")
+		if _, err := os.Stat(defaultFile); err == nil {
+			files = []string{defaultFile}
+		}
+	}
+	for _, file := range files {
+		env, err := readEnvFile(file)
+		if err != nil {
+			return err
+		}
+		for k, v := range env {
+			projectEnv[k] = v
+		}
+	}
+	for _, kv := range os.Environ() {
+		idx := strings.IndexByte(kv, '=')
+		if idx > 0 {
+			projectEnv[kv[:idx]] = kv[idx+1:]
+		}
+	}
+	return nil
+}
+
+func lookupEnv(name string) (string, bool) {
+	if projectEnv == nil {
+		return os.LookupEnv(name)
+	}
+	v, ok := projectEnv[name]
+	return v, ok
+}
+
+/*
+*
+读取 .env 格式的文件
+支持 # 注释、export 前缀、单引号和双引号
+*/
+func readEnvFile(file string) (map[string]string, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	env := map[string]string{}
+	lookup := func(name string) (string, bool) {
+		if v, ok := env[name]; ok {
+			return v, true
+		}
+		return os.LookupEnv(name)
+	}
+
+	scanner := bufio.NewScanner(f)
+	lineNum := 0
+	for scanner.Scan() {
+		lineNum++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		line = strings.TrimPrefix(line, "export ")
+		idx := strings.IndexByte(line, '=')
+		if idx <= 0 {
+			return nil, fmt.Errorf("%s:%d: invalid line %q", file, lineNum, line)
+		}
+		key := strings.TrimSpace(line[:idx])
+		value := strings.TrimSpace(line[idx+1:])
+		switch {
+		case len(value) >= 2 && value[0] == '\'' && value[len(value)-1] == '\'':
+			//单引号内不做任何处理
+			value = value[1 : len(value)-1]
+		case len(value) >= 2 && value[0] == '"' && value[len(value)-1] == '"':
+			value = strings.NewReplacer(`\n`, "\n", `\t`, "\t", `\"`, `"`, `\\`, `\`).Replace(value[1 : len(value)-1])
+			value, err = substitute(value, lookup)
+		default:
+			if i := strings.Index(value, " #"); i >= 0 {
+				value = strings.TrimSpace(value[:i])
+			}
+			value, err = substitute(value, lookup)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: %v", file, lineNum, err)
+		}
+		env[key] = value
+	}
+	return env, scanner.Err()
+}
+
+/*
+*
+对配置中的所有字符串做变量替换，直接修改 yaml 节点
+替换后按 schema 确定类型，不允许字符串的字段重新解析成数字、布尔等类型
+done 记录已经替换过的节点，锚点被多处引用时只替换一次
+*/
+func interpolateNode(file string, node *yaml.Node, s *schema, path string, done map[*yaml.Node]bool) error {
+	if node.Kind == yaml.AliasNode {
+		node = node.Alias
+	}
+	switch node.Kind {
+	case yaml.DocumentNode:
+		for _, item := range node.Content {
+			err := interpolateNode(file, item, s, path, done)
+			if err != nil {
+				return err
+			}
+		}
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			keyNode, valueNode := node.Content[i], node.Content[i+1]
+			//合并的字段属于当前的 map
+			if keyNode.Tag == "!!merge" {
+				err := interpolateMerge(file, valueNode, s, path, done)
+				if err != nil {
+					return err
+				}
+				continue
+			}
+			err := interpolateNode(file, valueNode, childSchema(s, keyNode.Value), joinPath(path, keyNode.Value), done)
+			if err != nil {
+				return err
+			}
+		}
+	case yaml.SequenceNode:
+		var items *schema
+		if s != nil {
+			items = s.items
+		}
+		for i, item := range node.Content {
+			err := interpolateNode(file, item, items, fmt.Sprintf("%s[%d]", path, i), done)
+			if err != nil {
+				return err
+			}
+		}
+	case yaml.ScalarNode:
+		if !done[node] {
+			if !strings.Contains(node.Value, "$") {
+				return nil
+			}
+			value, err := substitute(node.Value, lookupEnv)
+			if err != nil {
+				return fmt.Errorf("%s: %s: %v", file, path, err)
+			}
+			node.Value = value
+			done[node] = true
+		}
+		return coerceScalar(file, node, s, path)
+	}
+	return nil
+}
+
+func interpolateMerge(file string, node *yaml.Node, s *schema, path string, done map[*yaml.Node]bool) error {
+	if node.Kind == yaml.SequenceNode {
+		for _, item := range node.Content {
+			err := interpolateNode(file, item, s, path, done)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return interpolateNode(file, node, s, path, done)
+}
+
+// map 中 key 对应的 schema，未知的字段为 nil
+func childSchema(s *schema, key string) *schema {
+	if s == nil || strings.HasPrefix(key, "x-") {
+		return nil
+	}
+	if child, ok := s.keys[key]; ok {
+		return child
+	}
+	return s.values
+}
+
+/*
+*
+替换后的值按字段的类型重新解析
+字段允许字符串时保持字符串，否则去掉 !!str 标签由 yaml 重新确定类型，并检查类型和取值
+*/
+func coerceScalar(file string, node *yaml.Node, s *schema, path string) error {
+	if s == nil || s.kinds&kindAny != 0 {
+		return nil
+	}
+	if s.kinds&kindString == 0 {
+		node.Tag = ""
+		node.Style = 0
+		if s.kinds&kindOf(node) == 0 {
+			return fmt.Errorf("%s: %s must be %s, got %q", file, path, describeKinds(s.kinds), node.Value)
+		}
+	}
+	if len(s.enum) > 0 && !contains(s.enum, node.Value) {
+		return fmt.Errorf("%s: %s must be one of: %s", file, path, strings.Join(s.enum, ", "))
+	}
+	if s.check != nil {
+		if message := s.check(node.Value); message != "" {
+			return fmt.Errorf("%s: %s %s", file, path, message)
+		}
+	}
+	return nil
+}
+
+/*
+*
+替换字符串中的变量
+支持 $VAR ${VAR} ${VAR:-default} ${VAR-default} ${VAR:?err} ${VAR?err} ${VAR:+alt} ${VAR+alt}，$$ 表示 $
+*/
+func substitute(str string, lookup func(string) (string, bool)) (string, error) {
+	if !strings.Contains(str, "$") {
+		return str, nil
+	}
+	var builder strings.Builder
+	for i := 0; i < len(str); i++ {
+		c := str[i]
+		if c != '$' || i == len(str)-1 {
+			builder.WriteByte(c)
+			continue
+		}
+		next := str[i+1]
+		switch {
+		case next == '$':
+			builder.WriteByte('$')
+			i++
+		case next == '{':
+			end := matchingBrace(str, i+2)
+			if end == -1 {
+				return "", fmt.Errorf("invalid interpolation format for %q", str)
+			}
+			value, err := expandBraced(str[i+2:end], lookup)
+			if err != nil {
+				return "", err
+			}
+			builder.WriteString(value)
+			i = end
+		case isNameStart(next):
+			end := i + 1
+			for end < len(str) && isNameChar(str[end]) {
+				end++
+			}
+			value, _ := lookup(str[i+1 : end])
+			builder.WriteString(value)
+			i = end - 1
+		default:
+			builder.WriteByte(c)
+		}
+	}
+	return builder.String(), nil
+}
+
+// 找到与 ${ 对应的 }，默认值中可以嵌套变量
+func matchingBrace(str string, start int) int {
+	depth := 1
+	for i := start; i < len(str); i++ {
+		switch str[i] {
+		case '{':
+			depth++
+		case '}':
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+func expandBraced(expr string, lookup func(string) (string, bool)) (string, error) {
+	end := 0
+	for end < len(expr) && isNameChar(expr[end]) {
+		end++
+	}
+	name := expr[:end]
+	if name == "" || !isNameStart(name[0]) {
+		return "", fmt.Errorf("invalid interpolation format for \"${%s}\"", expr)
+	}
+	value, set := lookup(name)
+	if end == len(expr) {
+		return value, nil
+	}
+
+	rest := expr[end:]
+	colon := strings.HasPrefix(rest, ":")
+	if colon {
+		rest = rest[1:]
+	}
+	if rest == "" {
+		return "", fmt.Errorf("invalid interpolation format for \"${%s}\"", expr)
+	}
+	operator, arg := rest[0], rest[1:]
+	//带冒号时空字符串也视为未设置
+	present := set && (!colon || value != "")
+	switch operator {
+	case '-':
+		if present {
+			return value, nil
+		}
+		return substitute(arg, lookup)
+	case '?':
+		if present {
+			return value, nil
+		}
+		message, err := substitute(arg, lookup)
+		if err != nil {
+			return "", err
+		}
+		if message == "" {
+			message = "required variable " + name + " is missing a value"
+		} else {
+			message = "required variable " + name + " is missing a value: " + message
+		}
+		return "", fmt.Errorf("%s", message)
+	case '+':
+		if present {
+			return substitute(arg, lookup)
+		}
+		return "", nil
+	}
+	return "", fmt.Errorf("invalid interpolation format for \"${%s}\"", expr)
+}
+
+func isNameStart(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+func isNameChar(c byte) bool {
+	return isNameStart(c) || (c >= '0' && c <= '9')
+}
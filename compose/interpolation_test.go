@@ -0,0 +1,210 @@
+package compose
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSubstitute(t *testing.T) {
+	env := map[string]string{"NAME": "web", "EMPTY": "", "PORT": "8080"}
+	lookup := func(name string) (string, bool) {
+		v, ok := env[name]
+		return v, ok
+	}
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{"plain", "plain"},
+		{"$NAME", "web"},
+		{"${NAME}-1", "web-1"},
+		{"$NAME.$PORT", "web.8080"},
+		{"$UNSET", ""},
+		{"${UNSET:-default}", "default"},
+		{"${EMPTY:-default}", "default"},
+		{"${EMPTY-default}", ""},
+		{"${UNSET-default}", "default"},
+		{"${NAME:+alt}", "alt"},
+		{"${EMPTY:+alt}", ""},
+		{"${EMPTY+alt}", "alt"},
+		{"${UNSET+alt}", ""},
+		{"${UNSET:-${NAME}}", "web"},
+		{"$$NAME", "$NAME"},
+		{"cost $5", "cost $5"},
+		{"end$", "end$"},
+	}
+	for _, tt := range tests {
+		got, err := substitute(tt.input, lookup)
+		if err != nil {
+			t.Errorf("%q: %v", tt.input, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("%q: got %q, want %q", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestSubstituteErrors(t *testing.T) {
+	lookup := func(name string) (string, bool) {
+		return "", name == "EMPTY"
+	}
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{"${UNSET:?must be set}", "required variable UNSET is missing a value: must be set"},
+		{"${EMPTY:?}", "required variable EMPTY is missing a value"},
+		{"${UNSET?}", "required variable UNSET is missing a value"},
+		{"${NAME", "invalid interpolation format"},
+		{"${1BAD}", "invalid interpolation format"},
+		{"${NAME:}", "invalid interpolation format"},
+	}
+	for _, tt := range tests {
+		_, err := substitute(tt.input, lookup)
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%q: got %v, want %q", tt.input, err, tt.want)
+		}
+	}
+	//${EMPTY?} 变量已设置，不报错
+	if _, err := substitute("${EMPTY?}", lookup); err != nil {
+		t.Errorf("${EMPTY?}: %v", err)
+	}
+}
+
+func TestReadEnvFile(t *testing.T) {
+	file := filepath.Join(t.TempDir(), ".env")
+	content := `# comment
+A=1
+export B=two
+C="quoted # not a comment\nline"
+D='single $A'
+E=$A-${B}
+F=value # comment
+`
+	err := os.WriteFile(file, []byte(content), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	env, err := readEnvFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{
+		"A": "1",
+		"B": "two",
+		"C": "quoted # not a comment\nline",
+		"D": "single $A",
+		"E": "1-two",
+		"F": "value",
+	}
+	for key, value := range want {
+		if env[key] != value {
+			t.Errorf("%s: got %q, want %q", key, env[key], value)
+		}
+	}
+}
+
+// 替换后的值按字段类型解析，整数字段不会因为替换成字符串而无法解析
+func TestInterpolateTypedFields(t *testing.T) {
+	loadTestCompose(t, `
+x-common: &common
+  scale: ${SCALE:-1}
+services:
+  web:
+    <<: *common
+    image: nginx:${TAG}
+    cpu_shares: ${SHARES}
+    environment:
+      COUNT: ${N}
+      LITERAL: $$HOME
+    healthcheck:
+      test: ["CMD", "true"]
+      retries: ${RETRIES:-3}
+      disable: ${DISABLE:-false}
+  db:
+    image: postgres
+    deploy:
+      replicas: "${N}"
+`, map[string]string{"TAG": "1.25", "SHARES": "512", "N": "2", "RETRIES": "5"})
+
+	web := dockerCompose.Services["web"]
+	if web.Image != "nginx:1.25" {
+		t.Errorf("image: got %q", web.Image)
+	}
+	if web.Scale == nil || *web.Scale != 1 {
+		t.Errorf("scale: got %v", web.Scale)
+	}
+	if web.Healthcheck == nil || web.Healthcheck.Retries != 5 {
+		t.Errorf("healthcheck.retries: got %+v", web.Healthcheck)
+	}
+	env, err := web.GetEnvironment()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if env["COUNT"] != "2" || env["LITERAL"] != "$HOME" {
+		t.Errorf("environment: got %v", env)
+	}
+	resources, err := web.GetResources()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resources == nil || resources.CPU == nil || *resources.CPU.Shares != 512 {
+		t.Errorf("cpu_shares: got %+v", resources)
+	}
+	db := dockerCompose.Services["db"]
+	if replicas := db.GetReplicas(); replicas != 2 {
+		t.Errorf("deploy.replicas: got %d", replicas)
+	}
+}
+
+func TestInterpolateTypeErrors(t *testing.T) {
+	tests := []struct {
+		content string
+		env     map[string]string
+		want    string
+	}{
+		{`
+services:
+  web:
+    image: a
+    deploy:
+      replicas: ${N}
+`, map[string]string{"N": "two"}, "services.web.deploy.replicas must be an integer"},
+		{`
+services:
+  web:
+    image: a
+    healthcheck:
+      retries: ${R}
+`, map[string]string{"R": "3.5"}, "services.web.healthcheck.retries must be an integer"},
+		{`
+services:
+  web:
+    image: a
+    restart: ${RESTART}
+`, map[string]string{"RESTART": "sometimes"}, "services.web.restart must be one of"},
+		{`
+services:
+  web:
+    image: ${IMAGE:?image is required}
+`, nil, "services.web.image: required variable IMAGE is missing a value: image is required"},
+	}
+	for _, tt := range tests {
+		file := filepath.Join(t.TempDir(), "compose.yml")
+		err := os.WriteFile(file, []byte(tt.content), 0644)
+		if err != nil {
+			t.Fatal(err)
+		}
+		projectEnv = tt.env
+		if projectEnv == nil {
+			projectEnv = map[string]string{}
+		}
+		err = loadComposeFiles([]string{file})
+		if err == nil || !strings.Contains(err.Error(), tt.want) || !strings.HasPrefix(err.Error(), file+": ") {
+			t.Errorf("got %v, want %q", err, file+": ..."+tt.want)
+		}
+	}
+}
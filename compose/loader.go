@@ -0,0 +1,84 @@
+package compose
+
+import (
+	"fmt"
+	"gopkg.in/yaml.v3"
+	"io"
+	"os"
+)
+
+/*
+*
+读取并合并多个配置文件，后面的文件覆盖前面的
+*/
+func loadComposeFiles(files []string) error {
+	var merged map[string]any
+	for _, file := range files {
+		content, err := readComposeFile(file)
+		if err != nil {
+			return err
+		}
+		if merged == nil {
+			merged = content
+		} else {
+			merged = mergeComposeMap(merged, content)
+		}
+	}
+
+	//旧的配置 key 需要在转换长语法之前按原始配置计算
+	legacyKeys := legacyConfigKeys(merged)
+	err := shortenLongSyntax(merged)
+	if err != nil {
+		return err
+	}
+	data, err := yaml.Marshal(merged)
+	if err != nil {
+		return err
+	}
+	dockerCompose = DockerCompose{}
+	err = yaml.Unmarshal(data, &dockerCompose)
+	if err != nil {
+		return err
+	}
+	for name, service := range dockerCompose.Services {
+		service.legacyKey = legacyKeys[name]
+		dockerCompose.Services[name] = service
+	}
+	return nil
+}
+
+func readComposeFile(file string) (map[string]any, error) {
+	var data []byte
+	var err error
+	if file == "-" {
+		data, err = io.ReadAll(os.Stdin)
+	} else {
+		data, err = os.ReadFile(file)
+	}
+	if err != nil {
+		return nil, err
+	}
+	var node yaml.Node
+	err = yaml.Unmarshal(data, &node)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", file, err)
+	}
+	err = validateComposeFile(file, &node)
+	if err != nil {
+		return nil, err
+	}
+
+	//变量替换
+	err = interpolateNode(file, &node, composeSchema, "", map[*yaml.Node]bool{})
+	if err != nil {
+		return nil, err
+	}
+	content := map[string]any{}
+	if len(node.Content) > 0 {
+		err = node.Decode(&content)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", file, err)
+		}
+	}
+	return normalizeKeys(content).(map[string]any), nil
+}
@@ -0,0 +1,195 @@
+package compose
+
+import (
+	"fmt"
+	"strings"
+)
+
+// 覆盖而不是追加的列表字段
+var overrideSequenceKeys = map[string]bool{
+	"command":    true,
+	"entrypoint": true,
+	"test":       true,
+}
+
+// 以 key=value 形式书写、按 key 合并的字段
+var keyValueSequenceKeys = map[string]bool{
+	"environment": true,
+	"labels":      true,
+	"annotations": true,
+	"sysctls":     true,
+	"extra_hosts": true,
+}
+
+/*
+*
+按 compose 规范合并两个配置文件
+map 逐个 key 合并，列表追加并去重，标量直接覆盖
+*/
+func mergeComposeMap(base, override map[string]any) map[string]any {
+	return mergeValue(nil, base, override).(map[string]any)
+}
+
+func mergeValue(path []string, base, override any) any {
+	if override == nil {
+		return base
+	}
+	if base == nil {
+		return override
+	}
+	key := ""
+	if len(path) > 0 {
+		key = path[len(path)-1]
+	}
+	inService := len(path) == 3 && path[0] == "services"
+
+	//服务的 key=value 字段，列表和 map 两种写法统一成 map 合并
+	if inService && keyValueSequenceKeys[key] {
+		return mergeMap(path, toKeyValueMap(base, key), toKeyValueMap(override, key))
+	}
+	if inService && key == "depends_on" {
+		return mergeMap(path, toDependsOnMap(base), toDependsOnMap(override))
+	}
+
+	switch overrideValue := override.(type) {
+	case map[string]any:
+		baseValue, ok := base.(map[string]any)
+		if !ok {
+			return override
+		}
+		return mergeMap(path, baseValue, overrideValue)
+	case []any:
+		baseValue, ok := base.([]any)
+		if !ok || overrideSequenceKeys[key] {
+			return override
+		}
+		if inService && key == "volumes" {
+			return mergeVolumes(baseValue, overrideValue)
+		}
+		return appendUnique(baseValue, overrideValue)
+	default:
+		return override
+	}
+}
+
+func mergeMap(path []string, base, override map[string]any) map[string]any {
+	result := make(map[string]any, len(base)+len(override))
+	for k, v := range base {
+		result[k] = v
+	}
+	for k, v := range override {
+		if baseValue, exist := result[k]; exist {
+			result[k] = mergeValue(append(path[:len(path):len(path)], k), baseValue, v)
+		} else {
+			result[k] = v
+		}
+	}
+	return result
+}
+
+// 列表追加并去重
+func appendUnique(base, override []any) []any {
+	result := make([]any, 0, len(base)+len(override))
+	seen := map[string]bool{}
+	for _, item := range append(base[:len(base):len(base)], override...) {
+		key := fmt.Sprintf("%v", item)
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		result = append(result, item)
+	}
+	return result
+}
+
+// 挂载卷以容器内的路径为 key 合并，后面的文件覆盖前面的
+func mergeVolumes(base, override []any) []any {
+	result := make([]any, 0, len(base)+len(override))
+	index := map[string]int{}
+	for _, item := range append(base[:len(base):len(base)], override...) {
+		target := volumeTarget(item)
+		if i, exist := index[target]; exist && target != "" {
+			result[i] = item
+			continue
+		}
+		index[target] = len(result)
+		result = append(result, item)
+	}
+	return result
+}
+
+func volumeTarget(volume any) string {
+	switch v := volume.(type) {
+	case string:
+		parts := strings.Split(v, ":")
+		if len(parts) == 1 {
+			return parts[0]
+		}
+		return parts[1]
+	case map[string]any:
+		return fmt.Sprintf("%v", v["target"])
+	}
+	return fmt.Sprintf("%v", volume)
+}
+
+// key=value 列表转成 map
+func toKeyValueMap(value any, key string) map[string]any {
+	result := map[string]any{}
+	switch v := value.(type) {
+	case map[string]any:
+		return v
+	case []any:
+		separator := "="
+		if key == "extra_hosts" {
+			separator = ":"
+		}
+		for _, item := range v {
+			kv := fmt.Sprintf("%v", item)
+			idx := strings.Index(kv, separator)
+			if idx == -1 {
+				result[kv] = nil
+			} else {
+				result[kv[:idx]] = kv[idx+1:]
+			}
+		}
+	}
+	return result
+}
+
+// depends_on 短格式转成长格式
+func toDependsOnMap(value any) map[string]any {
+	switch v := value.(type) {
+	case map[string]any:
+		return v
+	case []any:
+		result := map[string]any{}
+		for _, item := range v {
+			result[fmt.Sprintf("%v", item)] = map[string]any{"condition": ConditionServiceStarted}
+		}
+		return result
+	}
+	return map[string]any{}
+}
+
+// yaml 中 key 不是字符串的 map 会被解析成 map[any]any，统一转换成 map[string]any
+func normalizeKeys(value any) any {
+	switch v := value.(type) {
+	case map[any]any:
+		result := make(map[string]any, len(v))
+		for k, item := range v {
+			result[fmt.Sprintf("%v", k)] = normalizeKeys(item)
+		}
+		return result
+	case map[string]any:
+		for k, item := range v {
+			v[k] = normalizeKeys(item)
+		}
+		return v
+	case []any:
+		for i, item := range v {
+			v[i] = normalizeKeys(item)
+		}
+		return v
+	}
+	return value
+}
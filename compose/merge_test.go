@@ -0,0 +1,180 @@
+package compose
+
+import (
+	"gopkg.in/yaml.v3"
+	"reflect"
+	"testing"
+)
+
+func parseTestYaml(t *testing.T, content string) map[string]any {
+	t.Helper()
+	result := map[string]any{}
+	err := yaml.Unmarshal([]byte(content), &result)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return normalizeKeys(result).(map[string]any)
+}
+
+func TestMergeComposeMap(t *testing.T) {
+	tests := []struct {
+		name     string
+		base     string
+		override string
+		want     string
+	}{
+		{"scalar override", `
+services:
+  web:
+    image: nginx:1
+    restart: always
+`, `
+services:
+  web:
+    image: nginx:2
+`, `
+services:
+  web:
+    image: nginx:2
+    restart: always
+`},
+		{"new service", `
+services:
+  web:
+    image: a
+`, `
+services:
+  db:
+    image: b
+`, `
+services:
+  web:
+    image: a
+  db:
+    image: b
+`},
+		{"command and entrypoint replaced", `
+services:
+  web:
+    command: [a, b]
+    entrypoint: [sh, -c]
+`, `
+services:
+  web:
+    command: [c]
+    entrypoint: [bash]
+`, `
+services:
+  web:
+    command: [c]
+    entrypoint: [bash]
+`},
+		{"ports appended without duplicates", `
+services:
+  web:
+    ports: ["80:80", "443:443"]
+`, `
+services:
+  web:
+    ports: ["443:443", "8080:8080"]
+`, `
+services:
+  web:
+    ports: ["80:80", "443:443", "8080:8080"]
+`},
+		{"environment list and map merged by key", `
+services:
+  web:
+    environment:
+      - A=1
+      - B=2
+`, `
+services:
+  web:
+    environment:
+      B: 3
+      C: 4
+`, `
+services:
+  web:
+    environment:
+      A: "1"
+      B: 3
+      C: 4
+`},
+		{"extra_hosts merged by host", `
+services:
+  web:
+    extra_hosts: ["a:1.1.1.1"]
+`, `
+services:
+  web:
+    extra_hosts: ["a:2.2.2.2", "b:3.3.3.3"]
+`, `
+services:
+  web:
+    extra_hosts:
+      a: 2.2.2.2
+      b: 3.3.3.3
+`},
+		{"volumes merged by target", `
+services:
+  web:
+    volumes: ["data:/data", "./conf:/etc/conf"]
+`, `
+services:
+  web:
+    volumes: ["other:/data", "/logs"]
+`, `
+services:
+  web:
+    volumes: ["other:/data", "./conf:/etc/conf", "/logs"]
+`},
+		{"depends_on short and long merged", `
+services:
+  web:
+    depends_on: [db]
+`, `
+services:
+  web:
+    depends_on:
+      cache:
+        condition: service_healthy
+`, `
+services:
+  web:
+    depends_on:
+      db:
+        condition: service_started
+      cache:
+        condition: service_healthy
+`},
+		{"top level maps merged", `
+volumes:
+  data:
+    driver: local
+networks:
+  front: {}
+`, `
+volumes:
+  data:
+    labels: {a: b}
+  logs: {}
+`, `
+volumes:
+  data:
+    driver: local
+    labels: {a: b}
+  logs: {}
+networks:
+  front: {}
+`},
+	}
+	for _, tt := range tests {
+		got := mergeComposeMap(parseTestYaml(t, tt.base), parseTestYaml(t, tt.override))
+		want := parseTestYaml(t, tt.want)
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("%s: got %v, want %v", tt.name, got, want)
+		}
+	}
+}
@@ -0,0 +1,349 @@
+package compose
+
+import (
+	"fmt"
+	"net/netip"
+	"podman-compose/cli"
+	"podman-compose/constant"
+	"podman-compose/util"
+	"sort"
+	"strings"
+)
+
+// DefaultNetwork 没有声明 networks 的服务使用的网络
+const DefaultNetwork = "default"
+
+// NetworkConfig 定义了顶层 networks 中的网络
+type NetworkConfig struct {
+	Name       string            `yaml:"name,omitempty"`
+	Driver     string            `yaml:"driver,omitempty"`
+	DriverOpts map[string]string `yaml:"driver_opts,omitempty"`
+	Internal   bool              `yaml:"internal,omitempty"`
+	EnableIPv6 bool              `yaml:"enable_ipv6,omitempty"`
+	Ipam       NetworkIpam       `yaml:"ipam,omitempty"`
+	External   any               `yaml:"external,omitempty"`
+	Labels     any               `yaml:"labels,omitempty"`
+}
+
+// NetworkIpam 网络的地址分配
+type NetworkIpam struct {
+	Driver  string              `yaml:"driver,omitempty"`
+	Config  []NetworkIpamConfig `yaml:"config,omitempty"`
+	Options map[string]string   `yaml:"options,omitempty"`
+}
+
+type NetworkIpamConfig struct {
+	Subnet  string `yaml:"subnet,omitempty"`
+	Gateway string `yaml:"gateway,omitempty"`
+	IPRange string `yaml:"ip_range,omitempty"`
+}
+
+// ServiceNetwork 服务加入网络时的配置
+type ServiceNetwork struct {
+	Aliases     []string
+	Ipv4Address string
+	Ipv6Address string
+}
+
+// IsExternal 是否是外部创建的网络
+func (c *NetworkConfig) IsExternal() bool {
+	switch v := c.External.(type) {
+	case bool:
+		return v
+	case map[string]any:
+		return true
+	}
+	return false
+}
+
+/*
+*
+解析服务的 networks，支持列表和 map 两种写法
+没有声明时使用默认网络，设置了 network_mode 时不加入任何网络
+*/
+func (c *ServiceConfig) GetNetworks() (map[string]ServiceNetwork, error) {
+	if strings.TrimSpace(c.NetworkMode) != "" {
+		return nil, nil
+	}
+	result := make(map[string]ServiceNetwork)
+	switch v := c.Networks.(type) {
+	case nil:
+		result[DefaultNetwork] = ServiceNetwork{}
+	case []any:
+		for _, item := range v {
+			name, ok := item.(string)
+			if !ok {
+				return nil, fmt.Errorf("networks \"%v\" format error", item)
+			}
+			result[name] = ServiceNetwork{}
+		}
+	case map[string]any:
+		for name, val := range v {
+			network := ServiceNetwork{}
+			if val != nil {
+				options, ok := val.(map[string]any)
+				if !ok {
+					return nil, fmt.Errorf("networks \"%s\" format error", name)
+				}
+				if aliases, ok := options["aliases"].([]any); ok {
+					for _, alias := range aliases {
+						network.Aliases = append(network.Aliases, fmt.Sprintf("%v", alias))
+					}
+				}
+				if ip, ok := options["ipv4_address"]; ok {
+					network.Ipv4Address = fmt.Sprintf("%v", ip)
+				}
+				if ip, ok := options["ipv6_address"]; ok {
+					network.Ipv6Address = fmt.Sprintf("%v", ip)
+				}
+			}
+			result[name] = network
+		}
+	default:
+		return nil, fmt.Errorf("networks format error")
+	}
+	return result, nil
+}
+
+// GetNetworkName 获取网络的实际名称，项目中的网络为 <project>_<key>
+func GetNetworkName(key string) string {
+	network, exist := dockerCompose.Networks[key]
+	if exist {
+		if network.Name != "" {
+			return network.Name
+		}
+		if external, ok := network.External.(map[string]any); ok {
+			if name, ok := external["name"].(string); ok && name != "" {
+				return name
+			}
+		}
+		if network.IsExternal() {
+			return key
+		}
+	}
+	return projectName + "_" + key
+}
+
+// 校验服务使用的网络都已经声明
+func checkNetworks() error {
+	for serviceName, service := range dockerCompose.Services {
+		networks, err := service.GetNetworks()
+		if err != nil {
+			return fmt.Errorf("service %s: %v", serviceName, err)
+		}
+		for key := range networks {
+			if _, exist := dockerCompose.Networks[key]; !exist && key != DefaultNetwork {
+				return fmt.Errorf("service %s refers to undefined network %s", serviceName, key)
+			}
+		}
+		if service.Networks != nil && strings.TrimSpace(service.NetworkMode) != "" {
+			return fmt.Errorf("service %s declares mutually exclusive `network_mode` and `networks`", serviceName)
+		}
+	}
+	for key, network := range dockerCompose.Networks {
+		_, err := ToStringMap(network.Labels, "labels")
+		if err != nil {
+			return fmt.Errorf("network %s: %v", key, err)
+		}
+		for _, config := range network.Ipam.Config {
+			if config.IPRange != "" {
+				_, err = parseIPRange(config.IPRange)
+				if err != nil {
+					return fmt.Errorf("network %s: %v", key, err)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+/*
+*
+创建服务使用的网络，已存在的跳过，外部网络必须已经存在
+*/
+func EnsureNetworks(serviceNames []string) error {
+	used := map[string]bool{}
+	for _, serviceName := range serviceNames {
+		service := dockerCompose.Services[serviceName]
+		networks, err := service.GetNetworks()
+		if err != nil {
+			return err
+		}
+		for key := range networks {
+			used[key] = true
+		}
+	}
+	keys := make([]string, 0, len(used))
+	for key := range used {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		network := dockerCompose.Networks[key]
+		name := GetNetworkName(key)
+		exist, err := cli.NetworkExists(name)
+		if err != nil {
+			return err
+		}
+		if exist {
+			continue
+		}
+		if network.IsExternal() {
+			return fmt.Errorf("external network \"%s\" not found", name)
+		}
+
+		labels, _ := ToStringMap(network.Labels, "labels")
+		if labels == nil {
+			labels = map[string]string{}
+		}
+		labels[constant.LabelComposeProject] = projectName
+		labels[constant.LabelComposeNetwork] = key
+
+		create := cli.Network{
+			Name:        name,
+			Driver:      network.Driver,
+			Internal:    network.Internal,
+			IPv6Enabled: network.EnableIPv6,
+			DNSEnabled:  true,
+			Labels:      labels,
+			Options:     network.DriverOpts,
+		}
+		if network.Ipam.Driver != "" {
+			create.IPAMOptions = map[string]string{"driver": network.Ipam.Driver}
+		}
+		for _, config := range network.Ipam.Config {
+			subnet := cli.Subnet{Subnet: config.Subnet, Gateway: config.Gateway}
+			if config.IPRange != "" {
+				subnet.LeaseRange, err = parseIPRange(config.IPRange)
+				if err != nil {
+					return err
+				}
+			}
+			create.Subnets = append(create.Subnets, subnet)
+		}
+
+		fmt.Print("Network " + name + " creating... ")
+		_, err = cli.CreateNetwork(create)
+		if err != nil {
+			return err
+		}
+		fmt.Println(util.TextColor(32, "done"))
+	}
+	return nil
+}
+
+/*
+*
+ip_range 是 CIDR 格式，转换成分配地址的起止范围
+IPv4 的网络地址和广播地址不能分配给容器
+*/
+func parseIPRange(ipRange string) (*cli.LeaseRange, error) {
+	prefix, err := netip.ParsePrefix(strings.TrimSpace(ipRange))
+	if err != nil {
+		return nil, fmt.Errorf("ip_range \"%s\" is invalid", ipRange)
+	}
+	prefix = prefix.Masked()
+	first := prefix.Addr()
+	//主机位全部置 1 得到最后一个地址
+	bytes := first.AsSlice()
+	for i := range bytes {
+		networkBits := prefix.Bits() - i*8
+		if networkBits <= 0 {
+			bytes[i] = 0xff
+		} else if networkBits < 8 {
+			bytes[i] |= 0xff >> networkBits
+		}
+	}
+	last, _ := netip.AddrFromSlice(bytes)
+	if prefix.Bits() < first.BitLen()-1 {
+		first = first.Next()
+		if first.Is4() {
+			last = last.Prev()
+		}
+	}
+	return &cli.LeaseRange{StartIP: first.String(), EndIP: last.String()}, nil
+}
+
+/*
+*
+删除当前项目创建的网络，外部网络以及还在被其它项目的容器使用的网络不会删除
+返回删除的网络
+*/
+func RemoveNetworks() ([]string, error) {
+	networks, err := cli.ListNetworks(map[string][]string{
+		"label": {constant.LabelComposeProject + "=" + projectName},
+	})
+	if err != nil {
+		return nil, err
+	}
+	var removed []string
+	for _, network := range networks {
+		key := network.Labels[constant.LabelComposeNetwork]
+		if config, exist := dockerCompose.Networks[key]; exist && config.IsExternal() {
+			continue
+		}
+		fmt.Print("Network " + network.Name + " removing... ")
+		users, err := ContainersUsing("network", network.Name)
+		if err != nil {
+			fmt.Println(err)
+			continue
+		}
+		if len(users) > 0 {
+			fmt.Println(util.TextColor(33, "skipped") + " in use by " + users[0].Names[0])
+			continue
+		}
+		err = cli.RemoveNetwork(network.Name, nil)
+		if err != nil {
+			fmt.Println(err)
+			continue
+		}
+		fmt.Println(util.TextColor(32, "done"))
+		removed = append(removed, network.Name)
+	}
+	return removed, nil
+}
+
+// 设置容器的网络
+func formatNetworks(spec *cli.SpecGenerator, serviceName string, service ServiceConfig) error {
+	networkMode := strings.TrimSpace(service.NetworkMode)
+	if networkMode != "" {
+		mode, value, _ := strings.Cut(networkMode, ":")
+		switch mode {
+		case "host", "none", "bridge", "private", "slirp4netns", "pasta":
+			spec.NetNS = &cli.Namespace{NSMode: mode}
+		case "container":
+			spec.NetNS = &cli.Namespace{NSMode: "from-container", Value: value}
+		case "service":
+			//依赖的服务可能刚刚创建，缓存的容器列表中还没有
+			container, exist, err := lookupServiceContainer(value)
+			if err != nil {
+				return err
+			}
+			if !exist {
+				return fmt.Errorf("network_mode service %s has no container", value)
+			}
+			spec.NetNS = &cli.Namespace{NSMode: "from-container", Value: container.ID}
+		default:
+			return fmt.Errorf("network_mode \"%s\" is invalid", networkMode)
+		}
+		return nil
+	}
+
+	networks, err := service.GetNetworks()
+	if err != nil {
+		return err
+	}
+	spec.Networks = make(map[string]cli.PerNetworkOptions, len(networks))
+	for key, network := range networks {
+		options := cli.PerNetworkOptions{Aliases: append([]string{serviceName}, network.Aliases...)}
+		if network.Ipv4Address != "" {
+			options.StaticIPs = append(options.StaticIPs, network.Ipv4Address)
+		}
+		if network.Ipv6Address != "" {
+			options.StaticIPs = append(options.StaticIPs, network.Ipv6Address)
+		}
+		spec.Networks[GetNetworkName(key)] = options
+	}
+	return nil
+}
@@ -0,0 +1,145 @@
+package compose
+
+import (
+	"podman-compose/cli"
+	"strings"
+	"testing"
+)
+
+func TestParseIPRange(t *testing.T) {
+	tests := []struct {
+		ipRange string
+		want    cli.LeaseRange
+	}{
+		{"172.28.5.0/24", cli.LeaseRange{StartIP: "172.28.5.1", EndIP: "172.28.5.254"}},
+		{"172.28.5.7/24", cli.LeaseRange{StartIP: "172.28.5.1", EndIP: "172.28.5.254"}},
+		{"10.0.0.0/30", cli.LeaseRange{StartIP: "10.0.0.1", EndIP: "10.0.0.2"}},
+		{"10.0.0.0/12", cli.LeaseRange{StartIP: "10.0.0.1", EndIP: "10.15.255.254"}},
+		{"10.0.0.4/31", cli.LeaseRange{StartIP: "10.0.0.4", EndIP: "10.0.0.5"}},
+		{"10.0.0.9/32", cli.LeaseRange{StartIP: "10.0.0.9", EndIP: "10.0.0.9"}},
+		{"fd00::/120", cli.LeaseRange{StartIP: "fd00::1", EndIP: "fd00::ff"}},
+		{" 192.168.0.0/16 ", cli.LeaseRange{StartIP: "192.168.0.1", EndIP: "192.168.255.254"}},
+	}
+	for _, tt := range tests {
+		got, err := parseIPRange(tt.ipRange)
+		if err != nil {
+			t.Errorf("%s: %v", tt.ipRange, err)
+			continue
+		}
+		if *got != tt.want {
+			t.Errorf("%s: got %+v, want %+v", tt.ipRange, *got, tt.want)
+		}
+	}
+	for _, ipRange := range []string{"172.28.5.0", "172.28.5.0/33", "range"} {
+		if _, err := parseIPRange(ipRange); err == nil {
+			t.Errorf("%q: expected an error", ipRange)
+		}
+	}
+}
+
+func TestCheckNetworks(t *testing.T) {
+	tests := []struct {
+		content string
+		want    string
+	}{
+		{`
+services:
+  web:
+    image: a
+    networks: [front]
+networks:
+  front:
+    ipam:
+      config:
+        - subnet: 172.28.0.0/16
+          ip_range: 172.28.5.0/24
+`, ""},
+		{`
+services:
+  web:
+    image: a
+    networks: [back]
+`, "service web refers to undefined network back"},
+		{`
+services:
+  web:
+    image: a
+    network_mode: host
+    networks: [default]
+`, "service web declares mutually exclusive `network_mode` and `networks`"},
+		{`
+services:
+  web:
+    image: a
+networks:
+  front:
+    ipam:
+      config:
+        - ip_range: 172.28.5.0
+`, "network front: ip_range \"172.28.5.0\" is invalid"},
+	}
+	for _, tt := range tests {
+		loadTestCompose(t, tt.content, nil)
+		err := checkNetworks()
+		if tt.want == "" {
+			if err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+			continue
+		}
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("got %v, want %q", err, tt.want)
+		}
+	}
+}
+
+func TestServiceGetNetworks(t *testing.T) {
+	loadTestCompose(t, `
+services:
+  plain:
+    image: a
+  list:
+    image: a
+    networks: [front, back]
+  detailed:
+    image: a
+    networks:
+      front:
+        aliases: [api]
+        ipv4_address: 172.28.0.10
+  sidecar:
+    image: a
+    network_mode: service:plain
+networks:
+  front: {}
+  back: {}
+`, nil)
+	tests := []struct {
+		service string
+		want    map[string]ServiceNetwork
+	}{
+		{"plain", map[string]ServiceNetwork{DefaultNetwork: {}}},
+		{"list", map[string]ServiceNetwork{"front": {}, "back": {}}},
+		{"detailed", map[string]ServiceNetwork{"front": {Aliases: []string{"api"}, Ipv4Address: "172.28.0.10"}}},
+		{"sidecar", nil},
+	}
+	for _, tt := range tests {
+		service := dockerCompose.Services[tt.service]
+		got, err := service.GetNetworks()
+		if err != nil {
+			t.Fatalf("%s: %v", tt.service, err)
+		}
+		if len(got) != len(tt.want) {
+			t.Errorf("%s: got %v, want %v", tt.service, got, tt.want)
+			continue
+		}
+		for name, network := range tt.want {
+			if strings.Join(got[name].Aliases, ",") != strings.Join(network.Aliases, ",") || got[name].Ipv4Address != network.Ipv4Address {
+				t.Errorf("%s: network %s got %+v, want %+v", tt.service, name, got[name], network)
+			}
+		}
+	}
+	if name := GetNetworkName("front"); name != "test_front" {
+		t.Errorf("network name: got %s", name)
+	}
+}
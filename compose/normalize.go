@@ -0,0 +1,187 @@
+package compose
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"gopkg.in/yaml.v3"
+	"strings"
+)
+
+/*
+*
+端口和挂载卷的长格式转换成短格式
+短格式无法表达的写法(tmpfs、只读的匿名卷等)直接报错，不静默丢弃
+*/
+func shortenLongSyntax(content map[string]any) error {
+	services, _ := content["services"].(map[string]any)
+	for serviceName, item := range services {
+		service, ok := item.(map[string]any)
+		if !ok {
+			continue
+		}
+		if ports, ok := service["ports"].([]any); ok {
+			for i, port := range ports {
+				if portMap, ok := port.(map[string]any); ok {
+					ports[i] = shortPort(portMap)
+				}
+			}
+		}
+		if volumes, ok := service["volumes"].([]any); ok {
+			for i, volume := range volumes {
+				if volumeMap, ok := volume.(map[string]any); ok {
+					short, err := shortVolume(volumeMap)
+					if err != nil {
+						return fmt.Errorf("service %s: %v", serviceName, err)
+					}
+					volumes[i] = short
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// [HOST_IP:][PUBLISHED:]TARGET[/PROTOCOL]，只有 host_ip 时为 HOST_IP::TARGET
+func shortPort(port map[string]any) string {
+	result := fmt.Sprintf("%v", port["target"])
+	published, hasPublished := port["published"]
+	hostIp, hasHostIp := port["host_ip"]
+	if hasPublished && published != nil {
+		result = fmt.Sprintf("%v:%s", published, result)
+		if hasHostIp && hostIp != nil {
+			result = fmt.Sprintf("%v:%s", hostIp, result)
+		}
+	} else if hasHostIp && hostIp != nil {
+		result = fmt.Sprintf("%v::%s", hostIp, result)
+	}
+	if protocol, ok := port["protocol"]; ok && protocol != nil {
+		result = fmt.Sprintf("%s/%v", result, protocol)
+	}
+	return result
+}
+
+/*
+*
+[SOURCE:]TARGET[:OPTIONS]，OPTIONS 为 ro、propagation、selinux 标记(z/Z)、nocopy
+*/
+func shortVolume(volume map[string]any) (string, error) {
+	target := fmt.Sprintf("%v", volume["target"])
+	volumeType, _ := volume["type"].(string)
+	if volumeType != "" && volumeType != "volume" && volumeType != "bind" {
+		return "", fmt.Errorf("volume %s: type %q is not supported", target, volumeType)
+	}
+	source, _ := volume["source"].(string)
+
+	var options []string
+	if readOnly, ok := volume["read_only"].(bool); ok && readOnly {
+		options = append(options, "ro")
+	}
+	if bind, ok := volume["bind"].(map[string]any); ok {
+		if propagation, ok := bind["propagation"]; ok && propagation != nil {
+			options = append(options, fmt.Sprintf("%v", propagation))
+		}
+		if selinux, ok := bind["selinux"]; ok && selinux != nil {
+			options = append(options, fmt.Sprintf("%v", selinux))
+		}
+		if create, ok := bind["create_host_path"].(bool); ok && !create {
+			return "", fmt.Errorf("volume %s: bind.create_host_path false is not supported", target)
+		}
+	}
+	if volumeOptions, ok := volume["volume"].(map[string]any); ok {
+		if nocopy, ok := volumeOptions["nocopy"].(bool); ok && nocopy {
+			options = append(options, "nocopy")
+		}
+	}
+
+	if source == "" {
+		//短格式的匿名卷只有容器内路径，无法带选项
+		if len(options) > 0 {
+			return "", fmt.Errorf("volume %s: options %s are not supported for anonymous volumes", target, strings.Join(options, ","))
+		}
+		return target, nil
+	}
+	result := source + ":" + target
+	if len(options) > 0 {
+		result += ":" + strings.Join(options, ",")
+	}
+	return result, nil
+}
+
+/*
+*
+获取规范化后的配置
+environment 统一为 map，depends_on 统一为长格式
+*/
+func GetNormalizedCompose() (DockerCompose, error) {
+	normalized := dockerCompose
+	normalized.Services = make(map[string]ServiceConfig, len(dockerCompose.Services))
+	for name, service := range dockerCompose.Services {
+		normalizedService, err := service.Normalize()
+		if err != nil {
+			return normalized, err
+		}
+		normalized.Services[name] = normalizedService
+	}
+	return normalized, nil
+}
+
+// Normalize 返回规范化后的服务配置
+func (c ServiceConfig) Normalize() (ServiceConfig, error) {
+	env, err := c.GetEnvironment()
+	if err != nil {
+		return c, err
+	}
+	if env != nil {
+		environment := make(map[string]any, len(env))
+		for k, v := range env {
+			environment[k] = v
+		}
+		c.Environment = environment
+	}
+
+	//只输出声明的依赖，不包括 network_mode 带来的隐式依赖
+	deps, err := c.getDeclaredDependsOn()
+	if err != nil {
+		return c, err
+	}
+	if deps != nil {
+		dependsOn := make(map[string]any, len(deps))
+		for name, dep := range deps {
+			dependsOn[name] = map[string]any{
+				"condition": dep.Condition,
+				"restart":   dep.Restart,
+				"required":  dep.Required,
+			}
+		}
+		c.DependsOn = dependsOn
+	}
+	return c, nil
+}
+
+// ToYaml 输出 yaml 格式
+func (c DockerCompose) ToYaml() ([]byte, error) {
+	var buf bytes.Buffer
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(2)
+	err := encoder.Encode(c)
+	if err != nil {
+		return nil, err
+	}
+	err = encoder.Close()
+	return buf.Bytes(), err
+}
+
+// ToJson 输出 json 格式，字段名与 yaml 一致
+func (c DockerCompose) ToJson() ([]byte, error) {
+	data, err := yaml.Marshal(c)
+	if err != nil {
+		return nil, err
+	}
+	var content any
+	err = yaml.Unmarshal(data, &content)
+	if err != nil {
+		return nil, err
+	}
+	return json.MarshalIndent(content, "", "  ")
+}
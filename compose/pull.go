@@ -0,0 +1,40 @@
+package compose
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+const (
+	PullPolicyAlways  = "always"
+	PullPolicyMissing = "missing"
+	PullPolicyNever   = "never"
+	PullPolicyBuild   = "build"
+)
+
+/*
+*
+解析 pull_policy，返回策略以及定期拉取的间隔
+daily、weekly、every_<duration> 统一返回 missing 以及对应的间隔，未配置时为 missing
+*/
+func (c *ServiceConfig) GetPullPolicy() (string, time.Duration, error) {
+	policy := strings.TrimSpace(c.PullPolicy)
+	switch policy {
+	case "", PullPolicyMissing, "if_not_present":
+		return PullPolicyMissing, 0, nil
+	case PullPolicyAlways, PullPolicyNever, PullPolicyBuild:
+		return policy, 0, nil
+	case "daily":
+		return PullPolicyMissing, 24 * time.Hour, nil
+	case "weekly":
+		return PullPolicyMissing, 7 * 24 * time.Hour, nil
+	}
+	if strings.HasPrefix(policy, "every_") {
+		interval, err := time.ParseDuration(strings.TrimPrefix(policy, "every_"))
+		if err == nil && interval > 0 {
+			return PullPolicyMissing, interval, nil
+		}
+	}
+	return "", 0, fmt.Errorf("pull_policy [%s] is Invalid", c.PullPolicy)
+}
@@ -0,0 +1,170 @@
+package compose
+
+import (
+	"fmt"
+	"os"
+	"podman-compose/cli"
+	"podman-compose/util"
+	"strconv"
+	"strings"
+)
+
+// cpus 换算成 CFS 配额时使用的周期(微秒)
+const cpuPeriod = 100000
+
+/*
+*
+合并后的资源限制
+优先级: deploy.resources > mem_limit、cpus 等服务下的旧字段 > 服务下的 resources
+reservations.cpus 没有对应的 cgroup 配置，不会生效
+没有任何限制时返回 nil
+*/
+func (c *ServiceConfig) GetResources() (*cli.LinuxResources, error) {
+	limits, reservations := c.mergeResources()
+
+	memory := &cli.LinuxMemory{}
+	cpu := &cli.LinuxCPU{Cpus: strings.TrimSpace(c.Cpuset)}
+	resources := &cli.LinuxResources{}
+
+	var err error
+	memory.Limit, err = parseSize(limits.Memory, "memory")
+	if err != nil {
+		return nil, err
+	}
+	memory.Reservation, err = parseSize(reservations.Memory, "memory reservation")
+	if err != nil {
+		return nil, err
+	}
+	//-1 表示不限制 swap，可以写成数字或字符串
+	if c.MemswapLimit != nil && strings.TrimSpace(fmt.Sprintf("%v", c.MemswapLimit)) == "-1" {
+		swap := int64(-1)
+		memory.Swap = &swap
+	} else {
+		memory.Swap, err = parseSize(c.MemswapLimit, "memswap_limit")
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	cpus, err := parseCPUs(limits.CPUs)
+	if err != nil {
+		return nil, err
+	}
+	if cpus > 0 {
+		period := uint64(cpuPeriod)
+		quota := int64(cpus * cpuPeriod)
+		cpu.Period = &period
+		cpu.Quota = &quota
+	}
+	if c.CPUShares != nil {
+		shares, err := strconv.ParseUint(fmt.Sprintf("%v", c.CPUShares), 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("cpu_shares [%v] is Invalid", c.CPUShares)
+		}
+		cpu.Shares = &shares
+	}
+
+	pids := limits.Pids
+	if pids == 0 && c.PidsLimit != nil {
+		pids, err = strconv.ParseInt(fmt.Sprintf("%v", c.PidsLimit), 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("pids_limit [%v] is Invalid", c.PidsLimit)
+		}
+	}
+	if pids != 0 {
+		resources.Pids = &cli.LinuxPids{Limit: pids}
+	}
+
+	if memory.Limit != nil || memory.Reservation != nil || memory.Swap != nil {
+		resources.Memory = memory
+	}
+	if cpu.Period != nil || cpu.Shares != nil || cpu.Cpus != "" {
+		resources.CPU = cpu
+	}
+	if resources.Memory == nil && resources.CPU == nil && resources.Pids == nil {
+		return nil, nil
+	}
+	return resources, nil
+}
+
+// 按优先级合并各处的资源配置
+func (c *ServiceConfig) mergeResources() (ResourceLimit, ResourceLimit) {
+	limits := c.Resources.Limits
+	reservations := c.Resources.Reservations
+	//旧字段
+	if c.MemLimit != nil {
+		limits.Memory = c.MemLimit
+	}
+	if c.CPUs != nil {
+		limits.CPUs = c.CPUs
+	}
+	if c.MemReservation != nil {
+		reservations.Memory = c.MemReservation
+	}
+	if c.Deploy != nil {
+		limits = mergeResourceLimit(limits, c.Deploy.Resources.Limits)
+		reservations = mergeResourceLimit(reservations, c.Deploy.Resources.Reservations)
+	}
+	return limits, reservations
+}
+
+// reservations.cpus 无法生效，配置了时输出警告
+func (c *ServiceConfig) checkCPUReservation(serviceName string) error {
+	_, reservations := c.mergeResources()
+	reserved, err := parseCPUs(reservations.CPUs)
+	if err != nil {
+		return err
+	}
+	if reserved > 0 {
+		fmt.Fprintf(os.Stderr, "WARN service %s: resources.reservations.cpus is not supported by podman and is ignored\n", serviceName)
+	}
+	return nil
+}
+
+// 后面的配置覆盖前面的配置中设置了的字段
+func mergeResourceLimit(base, override ResourceLimit) ResourceLimit {
+	if override.CPUs != nil {
+		base.CPUs = override.CPUs
+	}
+	if override.Memory != nil {
+		base.Memory = override.Memory
+	}
+	if override.Pids != 0 {
+		base.Pids = override.Pids
+	}
+	return base
+}
+
+// 容量可以是字节数或者带单位的字符串
+func parseSize(value any, field string) (*int64, error) {
+	if value == nil {
+		return nil, nil
+	}
+	var size int64
+	var err error
+	switch v := value.(type) {
+	case int:
+		if v < 0 {
+			return nil, fmt.Errorf("%s [%v] is Invalid", field, value)
+		}
+		size = int64(v)
+	default:
+		size, err = util.ParseSize(fmt.Sprintf("%v", v))
+		if err != nil {
+			return nil, fmt.Errorf("%s [%v] is Invalid", field, value)
+		}
+	}
+	return &size, nil
+}
+
+// cpus 可以是数字或字符串，例如 0.5、"1.5"
+func parseCPUs(value any) (float64, error) {
+	if value == nil {
+		return 0, nil
+	}
+	cpus, err := strconv.ParseFloat(strings.TrimSpace(fmt.Sprintf("%v", value)), 64)
+	if err != nil || cpus < 0 {
+		return 0, fmt.Errorf("cpus [%v] is Invalid", value)
+	}
+	return cpus, nil
+}
@@ -0,0 +1,136 @@
+package compose
+
+import (
+	"testing"
+)
+
+func TestGetResources(t *testing.T) {
+	loadTestCompose(t, `
+services:
+  none:
+    image: a
+  deploy:
+    image: a
+    deploy:
+      resources:
+        limits:
+          cpus: "1.5"
+          memory: 512m
+          pids: 100
+        reservations:
+          memory: 128m
+  legacy:
+    image: a
+    mem_limit: 256m
+    cpus: 0.5
+    mem_reservation: 64m
+    cpu_shares: 512
+    cpuset: "0,1"
+    pids_limit: 50
+  override:
+    image: a
+    mem_limit: 256m
+    resources:
+      limits:
+        memory: 128m
+    deploy:
+      resources:
+        limits:
+          memory: 1g
+  swap:
+    image: a
+    mem_limit: 64m
+    memswap_limit: -1
+  swapString:
+    image: a
+    mem_limit: 64m
+    memswap_limit: "-1"
+  reservedCPUs:
+    image: a
+    deploy:
+      resources:
+        reservations:
+          cpus: "2"
+`, nil)
+
+	resources := func(name string) *resourceSummary {
+		service := dockerCompose.Services[name]
+		result, err := service.GetResources()
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if result == nil {
+			return nil
+		}
+		summary := &resourceSummary{}
+		if result.Memory != nil {
+			summary.memory = deref(result.Memory.Limit)
+			summary.reservation = deref(result.Memory.Reservation)
+			summary.swap = deref(result.Memory.Swap)
+		}
+		if result.CPU != nil {
+			summary.quota = deref(result.CPU.Quota)
+			if result.CPU.Shares != nil {
+				summary.shares = int64(*result.CPU.Shares)
+			}
+			summary.cpuset = result.CPU.Cpus
+		}
+		if result.Pids != nil {
+			summary.pids = result.Pids.Limit
+		}
+		return summary
+	}
+
+	tests := []struct {
+		service string
+		want    *resourceSummary
+	}{
+		{"none", nil},
+		{"deploy", &resourceSummary{memory: 512 << 20, reservation: 128 << 20, quota: 150000, pids: 100}},
+		{"legacy", &resourceSummary{memory: 256 << 20, reservation: 64 << 20, quota: 50000, shares: 512, cpuset: "0,1", pids: 50}},
+		{"override", &resourceSummary{memory: 1 << 30}},
+		{"swap", &resourceSummary{memory: 64 << 20, swap: -1}},
+		{"swapString", &resourceSummary{memory: 64 << 20, swap: -1}},
+		//reservations.cpus 没有对应的设置，不会换算成 cpu_shares
+		{"reservedCPUs", nil},
+	}
+	for _, tt := range tests {
+		got := resources(tt.service)
+		if (got == nil) != (tt.want == nil) || (got != nil && *got != *tt.want) {
+			t.Errorf("%s: got %+v, want %+v", tt.service, got, tt.want)
+		}
+	}
+}
+
+func TestGetResourcesInvalid(t *testing.T) {
+	tests := []ServiceConfig{
+		{MemLimit: "lots"},
+		{CPUs: "many"},
+		{CPUShares: "-1"},
+		{MemswapLimit: "-2"},
+		{PidsLimit: "x"},
+	}
+	for _, service := range tests {
+		if _, err := service.GetResources(); err == nil {
+			t.Errorf("%+v: expected an error", service)
+		}
+	}
+}
+
+// 用于比较的资源限制，未设置的为 0
+type resourceSummary struct {
+	memory      int64
+	reservation int64
+	swap        int64
+	quota       int64
+	shares      int64
+	cpuset      string
+	pids        int64
+}
+
+func deref(value *int64) int64 {
+	if value == nil {
+		return 0
+	}
+	return *value
+}
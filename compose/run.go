@@ -0,0 +1,104 @@
+package compose
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"os"
+	"podman-compose/cli"
+	"podman-compose/constant"
+	"strings"
+)
+
+// RunOptions run 命令对服务配置的覆盖
+type RunOptions struct {
+	Name string
+	// 为空时使用服务的 command
+	Command []string
+	// 为 nil 时使用服务的 entrypoint
+	Entrypoint []string
+	Env        []string
+	Volumes    []string
+	Ports      []string
+	// 是否发布服务中配置的端口
+	ServicePorts bool
+	User         string
+	WorkDir      string
+}
+
+/*
+*
+根据服务配置生成一次性容器的参数
+不使用 container_name、restart，默认不发布服务的端口，并打上一次性容器的标签
+*/
+func GetRunSpec(serviceName string, service ServiceConfig, options RunOptions) (*cli.SpecGenerator, error) {
+	service.ContainerName = ""
+	service.Restart = ""
+
+	var ports []string
+	if options.ServicePorts {
+		ports = append(ports, service.Ports...)
+	}
+	service.Ports = append(ports, options.Ports...)
+
+	volumes := append([]string{}, service.Volumes...)
+	for _, volume := range options.Volumes {
+		parts := strings.SplitN(volume, ":", 2)
+		if len(parts) == 2 && (strings.HasPrefix(parts[0], ".") || strings.HasPrefix(parts[0], "~")) {
+			volume = ResolvePath(parts[0]) + ":" + parts[1]
+		}
+		volumes = append(volumes, volume)
+	}
+	service.Volumes = volumes
+
+	spec, err := GetContainerSpec(serviceName, service, 1)
+	if err != nil {
+		return nil, err
+	}
+
+	spec.Name = options.Name
+	if spec.Name == "" {
+		spec.Name = projectName + "-" + serviceName + "-run-" + randomID()
+	}
+	if len(options.Command) > 0 {
+		spec.Command = options.Command
+	}
+	if options.Entrypoint != nil {
+		spec.Entrypoint = options.Entrypoint
+	}
+	if options.User != "" {
+		spec.User = options.User
+	}
+	if options.WorkDir != "" {
+		spec.WorkDir = options.WorkDir
+	}
+
+	//-e KEY 没有值时使用当前环境变量的值
+	for _, env := range options.Env {
+		key, value, found := strings.Cut(env, "=")
+		if !found {
+			var ok bool
+			value, ok = os.LookupEnv(key)
+			if !ok {
+				continue
+			}
+		}
+		if spec.Env == nil {
+			spec.Env = map[string]string{}
+		}
+		spec.Env[key] = value
+	}
+
+	//一次性容器不参与配置比较和副本编号
+	delete(spec.Labels, constant.LabelContainerNumber)
+	delete(spec.Labels, constant.LabelConfigKey)
+	delete(spec.Labels, constant.LabelConfigHashVersion)
+	delete(spec.Labels, constant.LabelConfigFields)
+	spec.Labels[constant.LabelOneOff] = "true"
+	return spec, nil
+}
+
+func randomID() string {
+	b := make([]byte, 6)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,84 @@
+package compose
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+/*
+*
+服务的副本数，deploy.replicas 优先于 scale，默认为 1
+*/
+func (c *ServiceConfig) GetReplicas() int {
+	if c.Deploy != nil && c.Deploy.Replicas != nil {
+		return *c.Deploy.Replicas
+	}
+	if c.Scale != nil {
+		return *c.Scale
+	}
+	return 1
+}
+
+// 检查副本数，多副本时不能指定 container_name
+func checkReplicas(serviceName string, service ServiceConfig) error {
+	replicas := service.GetReplicas()
+	if replicas < 0 {
+		return fmt.Errorf("service %s: replicas must not be negative", serviceName)
+	}
+	if replicas > 1 && strings.TrimSpace(service.ContainerName) != "" {
+		return fmt.Errorf("service %s: container_name %s can not be used with more than one replica", serviceName, service.ContainerName)
+	}
+	return nil
+}
+
+// 根据服务名和副本数计算输出时服务名的宽度，多副本的服务输出时带上序号
+func updateServiceNameSize() {
+	size := 10
+	for serviceName, service := range dockerCompose.Services {
+		width := len(serviceName) + 1
+		if replicas := service.GetReplicas(); replicas > 1 {
+			width = len(serviceName) + len(strconv.Itoa(replicas)) + 2
+		}
+		if width > size {
+			size = width
+		}
+	}
+	fixServiceNameSize = size
+}
+
+/*
+*
+修改服务的副本数 (up --scale)，副本数不参与配置哈希，修改后不会导致重建
+*/
+func SetReplicas(serviceName string, replicas int) error {
+	service, exist := dockerCompose.Services[serviceName]
+	if !exist {
+		return fmt.Errorf("Service %s does not exist", serviceName)
+	}
+	deploy := DeployConfig{}
+	if service.Deploy != nil {
+		deploy = *service.Deploy
+	}
+	deploy.Replicas = &replicas
+	service.Deploy = &deploy
+	err := checkReplicas(serviceName, service)
+	if err != nil {
+		return err
+	}
+	dockerCompose.Services[serviceName] = service
+	updateServiceNameSize()
+	return nil
+}
+
+/*
+*
+输出时使用的名称，多副本的服务带上序号，例如 web-2
+*/
+func DisplayName(serviceName string, number int) string {
+	service := dockerCompose.Services[serviceName]
+	if service.GetReplicas() > 1 || number > 1 {
+		return serviceName + "-" + strconv.Itoa(number)
+	}
+	return serviceName
+}
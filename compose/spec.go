@@ -0,0 +1,251 @@
+package compose
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"podman-compose/cli"
+	"podman-compose/constant"
+	"strconv"
+	"strings"
+)
+
+/*
+*
+根据服务配置生成创建容器的参数
+*/
+func GetContainerSpec(serviceName string, service ServiceConfig, number int) (*cli.SpecGenerator, error) {
+	spec := &cli.SpecGenerator{
+		Name:    GetContainerName(serviceName, service, number),
+		Command: service.Command,
+		WorkDir: strings.TrimSpace(service.WorkingDir),
+		User:    strings.TrimSpace(service.User),
+	}
+
+	//镜像
+	image := GetImageName(serviceName, service)
+	if image == "" {
+		return nil, errors.New("image is required")
+	}
+	spec.Image = image
+
+	//Entrypoint
+	spec.Entrypoint = service.Entrypoint
+
+	// restart
+	err := formatRestart(spec, service.Restart)
+	if err != nil {
+		return nil, err
+	}
+
+	//停止信号和等待时间
+	signal, err := service.GetStopSignal()
+	if err != nil {
+		return nil, err
+	}
+	if signal != 0 {
+		spec.StopSignal = &signal
+	}
+	if strings.TrimSpace(service.StopGracePeriod) != "" {
+		timeout, err := service.GetStopTimeout()
+		if err != nil {
+			return nil, err
+		}
+		spec.StopTimeout = &timeout
+	}
+
+	//端口
+	for _, port := range service.Ports {
+		mapping, err := ParsePort(port)
+		if err != nil {
+			return nil, err
+		}
+		spec.PortMappings = append(spec.PortMappings, mapping)
+	}
+
+	//挂载卷
+	err = formatVolumes(spec, service.Volumes)
+	if err != nil {
+		return nil, err
+	}
+
+	//资源限制
+	spec.ResourceLimits, err = service.GetResources()
+	if err != nil {
+		return nil, err
+	}
+
+	//健康检查
+	spec.HealthConfig, err = service.Healthcheck.GetHealthConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	//网络
+	err = formatNetworks(spec, serviceName, service)
+	if err != nil {
+		return nil, err
+	}
+
+	//环境
+	spec.Env, err = service.GetEnvironment()
+	if err != nil {
+		return nil, err
+	}
+
+	//标签
+	hash, err := service.GetConfigHash()
+	if err != nil {
+		return nil, err
+	}
+	fieldHashes, err := service.GetFieldHashes()
+	if err != nil {
+		return nil, err
+	}
+	spec.Labels = map[string]string{
+		constant.LabelComposeProject:     projectName,
+		constant.LabelComposeDir:         GetComposeDir(),
+		constant.LabelComposeServiceName: serviceName,
+		constant.LabelContainerNumber:    strconv.Itoa(number),
+		constant.LabelConfigKey:          hash,
+		constant.LabelConfigHashVersion:  ConfigHashVersion,
+		constant.LabelConfigFields:       fieldHashes,
+	}
+	return spec, nil
+}
+
+// restart 策略，on-failure 可以带上重试次数
+func formatRestart(spec *cli.SpecGenerator, restart string) error {
+	restart = strings.TrimSpace(restart)
+	if restart == "" {
+		return nil
+	}
+	policy, retries, found := strings.Cut(restart, ":")
+	switch policy {
+	case "no", "always", "unless-stopped":
+		if found {
+			return fmt.Errorf("restart policy [%s] is Invalid", restart)
+		}
+	case "on-failure":
+		if found {
+			n, err := strconv.ParseUint(retries, 10, 32)
+			if err != nil {
+				return fmt.Errorf("restart policy [%s] is Invalid", restart)
+			}
+			tries := uint(n)
+			spec.RestartRetries = &tries
+		}
+	default:
+		return fmt.Errorf("restart policy [%s] is Invalid", restart)
+	}
+	spec.RestartPolicy = policy
+	return nil
+}
+
+// 挂载卷，绝对路径为绑定挂载，其它为命名卷
+func formatVolumes(spec *cli.SpecGenerator, volumes []string) error {
+	for _, volume := range volumes {
+		parts := strings.Split(volume, ":")
+		if len(parts) > 3 || parts[0] == "" {
+			return errors.New("volume [" + volume + "] is Invalid")
+		}
+		//只有容器内路径时为匿名卷
+		if len(parts) == 1 {
+			spec.Volumes = append(spec.Volumes, &cli.NamedVolume{Dest: parts[0]})
+			continue
+		}
+		var options []string
+		if len(parts) == 3 && parts[2] != "" {
+			options = strings.Split(parts[2], ",")
+		}
+		if filepath.IsAbs(parts[0]) {
+			spec.Mounts = append(spec.Mounts, cli.Mount{
+				Type:        "bind",
+				Source:      parts[0],
+				Destination: parts[1],
+				Options:     append([]string{"rbind"}, options...),
+			})
+		} else {
+			spec.Volumes = append(spec.Volumes, &cli.NamedVolume{Name: GetVolumeName(parts[0]), Dest: parts[1], Options: options})
+		}
+	}
+	return nil
+}
+
+/*
+*
+解析端口
+支持 [HOST_IP:][HOST_PORT:]CONTAINER_PORT[/PROTOCOL]，端口可以是范围 8000-8010
+*/
+func ParsePort(port string) (cli.PortMappingSpec, error) {
+	mapping := cli.PortMappingSpec{Protocol: "tcp"}
+	invalid := errors.New("port [" + port + "] is Invalid")
+
+	value := port
+	if idx := strings.LastIndexByte(value, '/'); idx != -1 {
+		mapping.Protocol = value[idx+1:]
+		value = value[:idx]
+		if mapping.Protocol != "tcp" && mapping.Protocol != "udp" && mapping.Protocol != "sctp" {
+			return mapping, invalid
+		}
+	}
+
+	var hostPort, containerPort string
+	//IPv6 地址写在方括号中
+	if strings.HasPrefix(value, "[") {
+		end := strings.Index(value, "]:")
+		if end == -1 {
+			return mapping, invalid
+		}
+		mapping.HostIP = value[1:end]
+		value = value[end+2:]
+	}
+	pair := strings.Split(value, ":")
+	switch len(pair) {
+	case 1:
+		containerPort = pair[0]
+	case 2:
+		hostPort, containerPort = pair[0], pair[1]
+	case 3:
+		if mapping.HostIP != "" {
+			return mapping, invalid
+		}
+		mapping.HostIP, hostPort, containerPort = pair[0], pair[1], pair[2]
+	default:
+		return mapping, invalid
+	}
+
+	containerStart, containerRange, err := parsePortRange(containerPort)
+	if err != nil {
+		return mapping, invalid
+	}
+	mapping.ContainerPort = containerStart
+	mapping.Range = containerRange
+	if hostPort != "" {
+		hostStart, hostRange, err := parsePortRange(hostPort)
+		if err != nil || hostRange != containerRange {
+			return mapping, invalid
+		}
+		mapping.HostPort = hostStart
+	}
+	if mapping.Range == 1 {
+		mapping.Range = 0
+	}
+	return mapping, nil
+}
+
+func parsePortRange(port string) (uint16, uint16, error) {
+	start, end, found := strings.Cut(port, "-")
+	startPort, err := strconv.ParseUint(start, 10, 16)
+	if err != nil {
+		return 0, 0, err
+	}
+	if !found {
+		return uint16(startPort), 1, nil
+	}
+	endPort, err := strconv.ParseUint(end, 10, 16)
+	if err != nil || endPort < startPort {
+		return 0, 0, errors.New("invalid port range")
+	}
+	return uint16(startPort), uint16(endPort-startPort) + 1, nil
+}
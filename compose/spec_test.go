@@ -0,0 +1,50 @@
+package compose
+
+import (
+	"podman-compose/cli"
+	"testing"
+)
+
+func TestParsePort(t *testing.T) {
+	tests := []struct {
+		port string
+		want cli.PortMappingSpec
+	}{
+		{"80", cli.PortMappingSpec{ContainerPort: 80, Protocol: "tcp"}},
+		{"8080:80", cli.PortMappingSpec{HostPort: 8080, ContainerPort: 80, Protocol: "tcp"}},
+		{"53:53/udp", cli.PortMappingSpec{HostPort: 53, ContainerPort: 53, Protocol: "udp"}},
+		{"127.0.0.1:8080:80", cli.PortMappingSpec{HostIP: "127.0.0.1", HostPort: 8080, ContainerPort: 80, Protocol: "tcp"}},
+		{"127.0.0.1::80", cli.PortMappingSpec{HostIP: "127.0.0.1", ContainerPort: 80, Protocol: "tcp"}},
+		{"[::1]:8080:80", cli.PortMappingSpec{HostIP: "::1", HostPort: 8080, ContainerPort: 80, Protocol: "tcp"}},
+		{"8000-8010:9000-9010", cli.PortMappingSpec{HostPort: 8000, ContainerPort: 9000, Range: 11, Protocol: "tcp"}},
+		{"9000-9001/sctp", cli.PortMappingSpec{ContainerPort: 9000, Range: 2, Protocol: "sctp"}},
+	}
+	for _, tt := range tests {
+		got, err := ParsePort(tt.port)
+		if err != nil {
+			t.Errorf("%s: %v", tt.port, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("%s: got %+v, want %+v", tt.port, got, tt.want)
+		}
+	}
+}
+
+func TestParsePortInvalid(t *testing.T) {
+	for _, port := range []string{
+		"",
+		"http",
+		"80/icmp",
+		"70000",
+		"1:2:3:4",
+		"8000-8010:9000-9005",
+		"9010-9000",
+		"[::1]8080:80",
+		"[::1]:1.2.3.4:8080:80",
+	} {
+		if _, err := ParsePort(port); err == nil {
+			t.Errorf("%q: expected an error", port)
+		}
+	}
+}
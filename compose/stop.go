@@ -0,0 +1,60 @@
+package compose
+
+import (
+	"fmt"
+	"golang.org/x/sys/unix"
+	"podman-compose/cli"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+// GetStopSignal 停止容器时发送的信号，没有配置时返回 0，使用镜像中的默认值
+func (c *ServiceConfig) GetStopSignal() (syscall.Signal, error) {
+	name := strings.ToUpper(strings.TrimSpace(c.StopSignal))
+	if name == "" {
+		return 0, nil
+	}
+	if number, err := strconv.Atoi(name); err == nil {
+		if number <= 0 || unix.SignalName(syscall.Signal(number)) == "" {
+			return 0, fmt.Errorf("stop_signal \"%s\" is invalid", c.StopSignal)
+		}
+		return syscall.Signal(number), nil
+	}
+	if !strings.HasPrefix(name, "SIG") {
+		name = "SIG" + name
+	}
+	signal := unix.SignalNum(name)
+	if signal == 0 {
+		return 0, fmt.Errorf("stop_signal \"%s\" is invalid", c.StopSignal)
+	}
+	return signal, nil
+}
+
+/*
+*
+停止服务的容器，timeout 为空时使用 stop_grace_period
+stop_signal 在创建容器时写入，由 podman 发送
+*/
+func StopContainer(service ServiceConfig, containerID string, timeout *uint) error {
+	if timeout == nil {
+		seconds, err := service.GetStopTimeout()
+		if err != nil {
+			return err
+		}
+		timeout = &seconds
+	}
+	return cli.Stop(containerID, timeout)
+}
+
+// RestartContainer 重启服务的容器，timeout 为空时使用 stop_grace_period
+func RestartContainer(service ServiceConfig, containerID string, timeout *uint) error {
+	if timeout == nil {
+		seconds, err := service.GetStopTimeout()
+		if err != nil {
+			return err
+		}
+		timeout = &seconds
+	}
+	return cli.Restart(containerID, timeout)
+}
@@ -0,0 +1,511 @@
+package compose
+
+import (
+	"fmt"
+	"gopkg.in/yaml.v3"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Strict 为 true 时未知的字段视为错误，否则只输出警告
+var Strict bool
+
+// 节点类型
+const (
+	kindAny = 1 << iota
+	kindString
+	kindInt
+	kindNumber
+	kindBool
+	kindMap
+	kindList
+	kindNull
+)
+
+// 配置文件结构的定义
+type schema struct {
+	kinds int
+	//map 中已知的字段
+	keys map[string]*schema
+	//map 中任意 key 对应的值
+	values *schema
+	//列表的元素
+	items *schema
+	//允许的取值
+	enum []string
+	//自定义检查，返回错误信息
+	check func(value string) string
+}
+
+func kind(kinds int) *schema {
+	return &schema{kinds: kinds}
+}
+
+func object(keys map[string]*schema) *schema {
+	return &schema{kinds: kindMap, keys: keys}
+}
+
+func mapOf(values *schema) *schema {
+	return &schema{kinds: kindMap, values: values}
+}
+
+func listOf(items *schema) *schema {
+	return &schema{kinds: kindList, items: items}
+}
+
+func enum(values ...string) *schema {
+	return &schema{kinds: kindString, enum: values}
+}
+
+func checked(kinds int, check func(value string) string) *schema {
+	return &schema{kinds: kinds, check: check}
+}
+
+var (
+	stringSchema     = kind(kindString)
+	intSchema        = kind(kindInt)
+	numberSchema     = kind(kindNumber | kindInt)
+	boolSchema       = kind(kindBool)
+	anySchema        = kind(kindAny)
+	stringListSchema = listOf(stringSchema)
+	stringOrList     = &schema{kinds: kindString | kindList, items: stringSchema}
+	//key=value 列表或者 map
+	listOrDict   = &schema{kinds: kindList | kindMap, items: stringSchema, values: kind(kindString | kindNumber | kindInt | kindBool | kindNull)}
+	sizeSchema   = kind(kindString | kindInt)
+	durationType = checked(kindString, checkDuration)
+)
+
+var ulimitsSchema = mapOf(&schema{kinds: kindInt | kindMap, keys: map[string]*schema{"soft": intSchema, "hard": intSchema}})
+
+var resourceSchema = object(map[string]*schema{
+	"cpus":              kind(kindString | kindNumber | kindInt),
+	"memory":            sizeSchema,
+	"pids":              intSchema,
+	"devices":           listOf(anySchema),
+	"generic_resources": listOf(anySchema),
+})
+
+var healthcheckSchema = object(map[string]*schema{
+	"test":           stringOrList,
+	"interval":       durationType,
+	"timeout":        durationType,
+	"retries":        intSchema,
+	"start_period":   durationType,
+	"start_interval": durationType,
+	"disable":        boolSchema,
+})
+
+var buildSchema = &schema{kinds: kindString | kindMap, keys: map[string]*schema{
+	"context":             stringSchema,
+	"dockerfile":          stringSchema,
+	"dockerfile_inline":   stringSchema,
+	"args":                listOrDict,
+	"ssh":                 listOrDict,
+	"labels":              listOrDict,
+	"cache_from":          stringListSchema,
+	"cache_to":            stringListSchema,
+	"no_cache":            boolSchema,
+	"additional_contexts": listOrDict,
+	"network":             stringSchema,
+	"pull":                boolSchema,
+	"target":              stringSchema,
+	"shm_size":            sizeSchema,
+	"extra_hosts":         listOrDict,
+	"isolation":           stringSchema,
+	"privileged":          boolSchema,
+	"secrets":             listOf(kind(kindString | kindMap)),
+	"tags":                stringListSchema,
+	"ulimits":             ulimitsSchema,
+	"platforms":           stringListSchema,
+}}
+
+var serviceSchema = object(map[string]*schema{
+	"annotations":         listOrDict,
+	"attach":              boolSchema,
+	"build":               buildSchema,
+	"blkio_config":        kind(kindMap),
+	"cap_add":             stringListSchema,
+	"cap_drop":            stringListSchema,
+	"cgroup":              enum("host", "private"),
+	"cgroup_parent":       stringSchema,
+	"command":             &schema{kinds: kindString | kindList | kindNull, items: stringSchema},
+	"configs":             listOf(kind(kindString | kindMap)),
+	"container_name":      stringSchema,
+	"cpu_count":           intSchema,
+	"cpu_percent":         numberSchema,
+	"cpu_shares":          kind(kindInt | kindString),
+	"cpu_quota":           kind(kindInt | kindString),
+	"cpu_period":          kind(kindInt | kindString),
+	"cpu_rt_period":       kind(kindInt | kindString),
+	"cpu_rt_runtime":      kind(kindInt | kindString),
+	"cpus":                kind(kindNumber | kindInt | kindString),
+	"cpuset":              stringSchema,
+	"credential_spec":     kind(kindMap),
+	"depends_on":          &schema{kinds: kindList | kindMap, items: stringSchema, values: dependsOnSchema},
+	"deploy":              deploySchema,
+	"develop":             kind(kindMap),
+	"device_cgroup_rules": stringListSchema,
+	"devices":             listOf(kind(kindString | kindMap)),
+	"dns":                 stringOrList,
+	"dns_opt":             stringListSchema,
+	"dns_search":          stringOrList,
+	"domainname":          stringSchema,
+	"entrypoint":          &schema{kinds: kindString | kindList | kindNull, items: stringSchema},
+	"env_file":            &schema{kinds: kindString | kindList, items: kind(kindString | kindMap)},
+	"environment":         listOrDict,
+	"expose":              listOf(kind(kindString | kindInt)),
+	"extends":             &schema{kinds: kindString | kindMap, keys: map[string]*schema{"service": stringSchema, "file": stringSchema}},
+	"external_links":      stringListSchema,
+	"extra_hosts":         listOrDict,
+	"group_add":           listOf(kind(kindString | kindInt)),
+	"healthcheck":         healthcheckSchema,
+	"hostname":            stringSchema,
+	"image":               stringSchema,
+	"init":                boolSchema,
+	"ipc":                 stringSchema,
+	"isolation":           stringSchema,
+	"labels":              listOrDict,
+	"links":               stringListSchema,
+	"logging":             object(map[string]*schema{"driver": stringSchema, "options": mapOf(kind(kindString | kindNumber | kindInt | kindNull))}),
+	"mac_address":         stringSchema,
+	"mem_limit":           sizeSchema,
+	"mem_reservation":     sizeSchema,
+	"mem_swappiness":      intSchema,
+	"memswap_limit":       sizeSchema,
+	"network_mode":        stringSchema,
+	"networks":            &schema{kinds: kindList | kindMap, items: stringSchema, values: serviceNetworkSchema},
+	"oom_kill_disable":    boolSchema,
+	"oom_score_adj":       intSchema,
+	"pid":                 kind(kindString | kindNull),
+	"pids_limit":          kind(kindInt | kindString),
+	"platform":            stringSchema,
+	"ports":               listOf(portSchema),
+	"privileged":          boolSchema,
+	"profiles":            stringListSchema,
+	"pull_policy":         checked(kindString, checkPullPolicy),
+	"read_only":           boolSchema,
+	"restart":             checked(kindString, checkRestart),
+	"runtime":             stringSchema,
+	"scale":               intSchema,
+	"secrets":             listOf(kind(kindString | kindMap)),
+	"security_opt":        stringListSchema,
+	"shm_size":            sizeSchema,
+	"stdin_open":          boolSchema,
+	"stop_grace_period":   durationType,
+	"stop_signal":         stringSchema,
+	"storage_opt":         kind(kindMap),
+	"sysctls":             listOrDict,
+	"tmpfs":               stringOrList,
+	"tty":                 boolSchema,
+	"ulimits":             ulimitsSchema,
+	"user":                stringSchema,
+	"userns_mode":         stringSchema,
+	"uts":                 stringSchema,
+	"volumes":             listOf(volumeSchema),
+	"volumes_from":        stringListSchema,
+	"working_dir":         stringSchema,
+	//历史版本中使用的资源限制写法
+	"resources": object(map[string]*schema{"limits": resourceSchema}),
+})
+
+var dependsOnSchema = object(map[string]*schema{
+	"condition": enum(ConditionServiceStarted, ConditionServiceHealthy, ConditionServiceCompletedSuccessfully),
+	"restart":   boolSchema,
+	"required":  boolSchema,
+})
+
+var deploySchema = object(map[string]*schema{
+	"mode":            enum("global", "replicated"),
+	"replicas":        intSchema,
+	"labels":          listOrDict,
+	"endpoint_mode":   enum("vip", "dnsrr"),
+	"update_config":   kind(kindMap),
+	"rollback_config": kind(kindMap),
+	"restart_policy":  kind(kindMap),
+	"placement":       kind(kindMap),
+	"resources": object(map[string]*schema{
+		"limits":       resourceSchema,
+		"reservations": resourceSchema,
+	}),
+})
+
+var serviceNetworkSchema = &schema{kinds: kindMap | kindNull, keys: map[string]*schema{
+	"aliases":        stringListSchema,
+	"ipv4_address":   stringSchema,
+	"ipv6_address":   stringSchema,
+	"link_local_ips": stringListSchema,
+	"mac_address":    stringSchema,
+	"driver_opts":    mapOf(kind(kindString | kindNumber | kindInt)),
+	"priority":       numberSchema,
+}}
+
+var portSchema = &schema{kinds: kindString | kindInt | kindMap, check: checkPort, keys: map[string]*schema{
+	"name":         stringSchema,
+	"mode":         enum("host", "ingress"),
+	"host_ip":      stringSchema,
+	"target":       kind(kindInt | kindString),
+	"published":    kind(kindInt | kindString),
+	"protocol":     enum("tcp", "udp", "sctp"),
+	"app_protocol": stringSchema,
+}}
+
+var volumeSchema = &schema{kinds: kindString | kindMap, check: checkVolume, keys: map[string]*schema{
+	"type":        enum("bind", "volume", "tmpfs", "npipe", "cluster"),
+	"source":      stringSchema,
+	"target":      stringSchema,
+	"read_only":   boolSchema,
+	"consistency": stringSchema,
+	"bind":        object(map[string]*schema{"propagation": stringSchema, "create_host_path": boolSchema, "selinux": enum("z", "Z")}),
+	"volume":      object(map[string]*schema{"nocopy": boolSchema, "subpath": stringSchema}),
+	"tmpfs":       object(map[string]*schema{"size": sizeSchema, "mode": numberSchema}),
+}}
+
+var topLevelVolumeSchema = &schema{kinds: kindMap | kindNull, keys: map[string]*schema{
+	"name":        stringSchema,
+	"driver":      stringSchema,
+	"driver_opts": mapOf(kind(kindString | kindNumber | kindInt)),
+	"external":    &schema{kinds: kindBool | kindMap, keys: map[string]*schema{"name": stringSchema}},
+	"labels":      listOrDict,
+}}
+
+var topLevelNetworkSchema = &schema{kinds: kindMap | kindNull, keys: map[string]*schema{
+	"name":        stringSchema,
+	"driver":      stringSchema,
+	"driver_opts": mapOf(kind(kindString | kindNumber | kindInt)),
+	"attachable":  boolSchema,
+	"enable_ipv6": boolSchema,
+	"ipam":        object(map[string]*schema{"driver": stringSchema, "config": listOf(kind(kindMap)), "options": mapOf(stringSchema)}),
+	"internal":    boolSchema,
+	"external":    &schema{kinds: kindBool | kindMap, keys: map[string]*schema{"name": stringSchema}},
+	"labels":      listOrDict,
+}}
+
+var fileObjectSchema = object(map[string]*schema{
+	"name":            stringSchema,
+	"file":            stringSchema,
+	"environment":     stringSchema,
+	"content":         stringSchema,
+	"external":        &schema{kinds: kindBool | kindMap, keys: map[string]*schema{"name": stringSchema}},
+	"labels":          listOrDict,
+	"driver":          stringSchema,
+	"driver_opts":     mapOf(kind(kindString | kindNumber | kindInt)),
+	"template_driver": stringSchema,
+})
+
+var composeSchema = object(map[string]*schema{
+	"version":  stringSchema,
+	"name":     stringSchema,
+	"include":  listOf(kind(kindString | kindMap)),
+	"services": mapOf(serviceSchema),
+	"volumes":  mapOf(topLevelVolumeSchema),
+	"networks": mapOf(topLevelNetworkSchema),
+	"configs":  mapOf(fileObjectSchema),
+	"secrets":  mapOf(fileObjectSchema),
+})
+
+// 校验发现的问题
+type validationProblem struct {
+	file    string
+	line    int
+	column  int
+	message string
+	//未知字段，非 strict 模式下只警告
+	unknown bool
+}
+
+func (p validationProblem) String() string {
+	return fmt.Sprintf("%s:%d:%d: %s", p.file, p.line, p.column, p.message)
+}
+
+/*
+*
+按 compose 规范校验配置文件
+类型错误直接报错，未知字段在 --strict 时报错，否则输出警告，x- 开头的扩展字段不做检查
+*/
+func validateComposeFile(file string, node *yaml.Node) error {
+	if node.Kind == yaml.DocumentNode {
+		if len(node.Content) == 0 {
+			return nil
+		}
+		node = node.Content[0]
+	}
+	var problems []validationProblem
+	validateNode(file, node, composeSchema, "", &problems)
+
+	var errs []string
+	for _, problem := range problems {
+		if problem.unknown && !Strict {
+			fmt.Fprintln(os.Stderr, "WARN "+problem.String())
+			continue
+		}
+		errs = append(errs, problem.String())
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("invalid compose file:\n  %s", strings.Join(errs, "\n  "))
+	}
+	return nil
+}
+
+func validateNode(file string, node *yaml.Node, s *schema, path string, problems *[]validationProblem) {
+	report := func(n *yaml.Node, unknown bool, format string, args ...any) {
+		*problems = append(*problems, validationProblem{file, n.Line, n.Column, fmt.Sprintf(format, args...), unknown})
+	}
+	if node.Kind == yaml.AliasNode {
+		node = node.Alias
+	}
+	if s.kinds&kindAny != 0 {
+		return
+	}
+
+	nodeKind := kindOf(node)
+	if s.kinds&nodeKind == 0 {
+		//包含变量的字符串在替换后才能确定类型
+		if !(nodeKind == kindString && strings.Contains(node.Value, "$")) {
+			report(node, false, "%s must be %s", path, describeKinds(s.kinds))
+		}
+		return
+	}
+
+	switch nodeKind {
+	case kindString, kindInt:
+		if len(s.enum) > 0 && !strings.Contains(node.Value, "$") && !contains(s.enum, node.Value) {
+			report(node, false, "%s must be one of: %s", path, strings.Join(s.enum, ", "))
+		}
+		if s.check != nil && !strings.Contains(node.Value, "$") {
+			if message := s.check(node.Value); message != "" {
+				report(node, false, "%s %s", path, message)
+			}
+		}
+	case kindMap:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			keyNode, valueNode := node.Content[i], node.Content[i+1]
+			key := keyNode.Value
+			childPath := joinPath(path, key)
+			if keyNode.Tag == "!!merge" || strings.HasPrefix(key, "x-") {
+				continue
+			}
+			if childSchema, ok := s.keys[key]; ok {
+				validateNode(file, valueNode, childSchema, childPath, problems)
+			} else if s.values != nil {
+				validateNode(file, valueNode, s.values, childPath, problems)
+			} else if s.keys != nil {
+				report(keyNode, true, "%s is not a known key", childPath)
+			}
+		}
+	case kindList:
+		if s.items == nil {
+			return
+		}
+		for i, item := range node.Content {
+			validateNode(file, item, s.items, fmt.Sprintf("%s[%d]", path, i), problems)
+		}
+	}
+}
+
+func kindOf(node *yaml.Node) int {
+	switch node.Kind {
+	case yaml.MappingNode:
+		return kindMap
+	case yaml.SequenceNode:
+		return kindList
+	case yaml.ScalarNode:
+		switch node.ShortTag() {
+		case "!!null":
+			return kindNull
+		case "!!bool":
+			return kindBool
+		case "!!int":
+			return kindInt
+		case "!!float":
+			return kindNumber
+		}
+	}
+	return kindString
+}
+
+func describeKinds(kinds int) string {
+	var names []string
+	for _, k := range []struct {
+		kind int
+		name string
+	}{{kindString, "a string"}, {kindInt, "an integer"}, {kindNumber, "a number"}, {kindBool, "a boolean"}, {kindMap, "a mapping"}, {kindList, "a list"}, {kindNull, "null"}} {
+		if kinds&k.kind != 0 {
+			names = append(names, k.name)
+		}
+	}
+	return strings.Join(names, " or ")
+}
+
+func joinPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func checkDuration(value string) string {
+	if _, err := time.ParseDuration(value); err != nil {
+		return "is not a valid duration"
+	}
+	return ""
+}
+
+func checkRestart(value string) string {
+	policy, retries, found := strings.Cut(value, ":")
+	switch policy {
+	case "no", "always", "unless-stopped":
+		if !found {
+			return ""
+		}
+	case "on-failure":
+		if !found {
+			return ""
+		}
+		if _, err := strconv.ParseUint(retries, 10, 32); err == nil {
+			return ""
+		}
+	}
+	return "must be one of: no, always, on-failure[:max-retries], unless-stopped"
+}
+
+func checkPullPolicy(value string) string {
+	switch value {
+	case "always", "never", "missing", "if_not_present", "build", "daily", "weekly":
+		return ""
+	}
+	if strings.HasPrefix(value, "every_") {
+		if _, err := time.ParseDuration(strings.TrimPrefix(value, "every_")); err == nil {
+			return ""
+		}
+	}
+	return "must be one of: always, never, missing, build, daily, weekly, every_<duration>"
+}
+
+func checkPort(value string) string {
+	if _, err := ParsePort(value); err != nil {
+		return "is not a valid port mapping"
+	}
+	return ""
+}
+
+func checkVolume(value string) string {
+	parts := strings.Split(value, ":")
+	if len(parts) > 3 || parts[0] == "" {
+		return "is not a valid volume mapping"
+	}
+	if len(parts) >= 2 && !strings.HasPrefix(parts[1], "/") {
+		return "target must be an absolute path"
+	}
+	return ""
+}
@@ -0,0 +1,138 @@
+package compose
+
+import (
+	"fmt"
+	"podman-compose/cli"
+	"podman-compose/constant"
+	"podman-compose/util"
+	"sort"
+)
+
+// VolumeConfig 定义了顶层 volumes 中的卷
+type VolumeConfig struct {
+	Name       string            `yaml:"name,omitempty"`
+	Driver     string            `yaml:"driver,omitempty"`
+	DriverOpts map[string]string `yaml:"driver_opts,omitempty"`
+	External   any               `yaml:"external,omitempty"`
+	Labels     any               `yaml:"labels,omitempty"`
+}
+
+// IsExternal 是否是外部创建的卷，支持 external: true 和旧的 external: {name: xxx} 写法
+func (c *VolumeConfig) IsExternal() bool {
+	switch v := c.External.(type) {
+	case bool:
+		return v
+	case map[string]any:
+		return true
+	}
+	return false
+}
+
+// GetVolumeName 获取卷的实际名称，项目中的卷为 <project>_<key>
+func GetVolumeName(key string) string {
+	volume, exist := dockerCompose.Volumes[key]
+	if !exist {
+		return key
+	}
+	if volume.Name != "" {
+		return volume.Name
+	}
+	if external, ok := volume.External.(map[string]any); ok {
+		if name, ok := external["name"].(string); ok && name != "" {
+			return name
+		}
+	}
+	if volume.IsExternal() {
+		return key
+	}
+	return projectName + "_" + key
+}
+
+/*
+*
+创建配置文件中声明的卷，已存在的跳过，外部卷必须已经存在
+*/
+func EnsureVolumes() error {
+	keys := make([]string, 0, len(dockerCompose.Volumes))
+	for key := range dockerCompose.Volumes {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		volume := dockerCompose.Volumes[key]
+		name := GetVolumeName(key)
+		exist, err := cli.VolumeExists(name)
+		if err != nil {
+			return err
+		}
+		if exist {
+			continue
+		}
+		if volume.IsExternal() {
+			return fmt.Errorf("external volume \"%s\" not found", name)
+		}
+
+		labels, err := ToStringMap(volume.Labels, "labels")
+		if err != nil {
+			return fmt.Errorf("volume %s: %v", key, err)
+		}
+		if labels == nil {
+			labels = map[string]string{}
+		}
+		labels[constant.LabelComposeProject] = projectName
+		labels[constant.LabelComposeVolume] = key
+
+		fmt.Print("Volume " + name + " creating... ")
+		_, err = cli.CreateVolume(cli.VolumeCreateOptions{
+			Name:    name,
+			Driver:  volume.Driver,
+			Label:   labels,
+			Options: volume.DriverOpts,
+		})
+		if err != nil {
+			return err
+		}
+		fmt.Println(util.TextColor(32, "done"))
+	}
+	return nil
+}
+
+/*
+*
+删除当前项目创建的卷，外部卷以及还在被其它容器使用的卷不会删除
+返回删除的卷
+*/
+func RemoveVolumes() ([]string, error) {
+	volumes, err := cli.ListVolumes(map[string][]string{
+		"label": {constant.LabelComposeProject + "=" + projectName},
+	})
+	if err != nil {
+		return nil, err
+	}
+	var removed []string
+	for _, volume := range volumes {
+		key := volume.Labels[constant.LabelComposeVolume]
+		if config, exist := dockerCompose.Volumes[key]; exist && config.IsExternal() {
+			continue
+		}
+		fmt.Print("Volume " + volume.Name + " removing... ")
+		users, err := ContainersUsing("volume", volume.Name)
+		if err != nil {
+			fmt.Println(err)
+			continue
+		}
+		if len(users) > 0 {
+			fmt.Println(util.TextColor(33, "skipped") + " in use by " + users[0].Names[0])
+			continue
+		}
+		err = cli.RemoveVolume(volume.Name, nil)
+		if err != nil {
+			fmt.Println(err)
+			continue
+		}
+		fmt.Println(util.TextColor(32, "done"))
+		removed = append(removed, volume.Name)
+	}
+	return removed, nil
+}
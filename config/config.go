@@ -0,0 +1,112 @@
+package config
+
+import (
+	"fmt"
+	"github.com/spf13/cobra"
+	"os"
+	"podman-compose/compose"
+	"podman-compose/registry"
+	"sort"
+	"strings"
+)
+
+var configCmd = &cobra.Command{
+	Use:   "config",
+	Short: "Parse, resolve and render compose file in canonical format",
+	Run:   config,
+}
+
+var format = "yaml"
+var services = false
+var volumes = false
+var images = false
+var hash = ""
+var quiet = false
+
+func init() {
+	configCmd.Flags().StringVarP(&format, "format", "", "yaml", "Format the output. Values: [yaml | json]")
+	configCmd.Flags().BoolVarP(&services, "services", "", false, "Print the service names, one per line.")
+	configCmd.Flags().BoolVarP(&volumes, "volumes", "", false, "Print the volume names, one per line.")
+	configCmd.Flags().BoolVarP(&images, "images", "", false, "Print the image names, one per line.")
+	configCmd.Flags().StringVarP(&hash, "hash", "", "", "Print the service config hash, one per line. Set \"service1,service2\" for a list of specified services or use \"*\" for all services.")
+	configCmd.Flags().BoolVarP(&quiet, "quiet", "q", false, "Only validate the configuration, don't print anything.")
+	registry.Commands = append(registry.Commands, configCmd)
+}
+
+func config(cmd *cobra.Command, args []string) {
+	//配置已经在启动时解析，能执行到这里说明配置是合法的
+	if quiet {
+		return
+	}
+
+	dockerCompose, err := compose.GetNormalizedCompose()
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+
+	switch {
+	case services:
+		levels, err := compose.GetStartOrder(nil, true)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		for _, level := range levels {
+			for _, name := range level {
+				fmt.Println(name)
+			}
+		}
+	case volumes:
+		for _, name := range sortedKeys(dockerCompose.Volumes) {
+			fmt.Println(name)
+		}
+	case images:
+		for _, name := range sortedKeys(dockerCompose.Services) {
+			fmt.Println(compose.GetImageName(name, dockerCompose.Services[name]))
+		}
+	case hash != "":
+		names := strings.Split(hash, ",")
+		if hash == "*" {
+			names = sortedKeys(dockerCompose.Services)
+		}
+		for _, name := range names {
+			service, exist := compose.GetDockerCompose().Services[name]
+			if !exist {
+				fmt.Printf("Service %s does not exist\n", name)
+				os.Exit(1)
+			}
+			configHash, err := service.GetConfigHash()
+			if err != nil {
+				fmt.Println(name, ":", err)
+				os.Exit(1)
+			}
+			fmt.Println(name, configHash)
+		}
+	default:
+		var data []byte
+		switch format {
+		case "yaml":
+			data, err = dockerCompose.ToYaml()
+		case "json":
+			data, err = dockerCompose.ToJson()
+			data = append(data, '\n')
+		default:
+			err = fmt.Errorf("unsupported format %q", format)
+		}
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		os.Stdout.Write(data)
+	}
+}
+
+func sortedKeys[T any](m map[string]T) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
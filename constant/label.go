@@ -1,5 +1,12 @@
 package constant
 
 const LabelComposeDir = "compose-dir"
+const LabelComposeProject = "compose-project"
 const LabelComposeServiceName = "compose-service-name"
 const LabelConfigKey = "compose-config-key"
+const LabelConfigHashVersion = "compose-config-hash-version"
+const LabelConfigFields = "compose-config-fields"
+const LabelComposeVolume = "compose-volume"
+const LabelComposeNetwork = "compose-network"
+const LabelContainerNumber = "compose-container-number"
+const LabelOneOff = "compose-oneoff"
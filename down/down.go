@@ -9,6 +9,8 @@ import (
 	"podman-compose/constant"
 	"podman-compose/registry"
 	"podman-compose/util"
+	"sync"
+	"time"
 )
 
 var downCmd = &cobra.Command{
@@ -20,85 +22,210 @@ var downCmd = &cobra.Command{
 // 删除孤立项
 var removeOrphans = false
 
+// 等待容器停止的秒数，没有指定时使用服务的 stop_grace_period
+var timeout uint = 10
+
+// 删除卷
+var volumes = false
+
+// 删除镜像，all 或 local
+var rmi = ""
+
 func init() {
+	downCmd.Flags().BoolVarP(&volumes, "volumes", "v", false, "Remove named volumes declared in the \"volumes\" section of the Compose file and anonymous volumes attached to containers")
+	downCmd.Flags().StringVarP(&rmi, "rmi", "", "", "Remove images used by services. \"local\" remove only images that don't have a custom tag (\"local\"|\"all\")")
+	downCmd.Flags().UintVarP(&timeout, "timeout", "t", 10, "Specify a shutdown timeout in seconds")
 	downCmd.Flags().BoolVarP(&removeOrphans, "remove-orphans", "", false, "Remove containers for services not defined in the Compose file")
 	registry.Commands = append(registry.Commands, downCmd)
 }
 
 func down(cmd *cobra.Command, args []string) {
-	var err error
-
+	//按依赖关系的逆序停止，先停止依赖方
+	levels, err := compose.GetStartOrder(args, false)
 	if err != nil {
 		fmt.Println(err)
 		os.Exit(1)
 	}
+	var stopTimeout *uint
+	if cmd.Flags().Changed("timeout") {
+		stopTimeout = &timeout
+	}
 
-	services := map[string]string{}
-	for _, arg := range args {
-		services[arg] = "1"
+	if rmi != "" && rmi != compose.RemoveImagesAll && rmi != compose.RemoveImagesLocal {
+		fmt.Printf("invalid --rmi value %q, must be one of [all | local]\n", rmi)
+		os.Exit(1)
 	}
 
-	dockerCompose := compose.GetDockerCompose()
-	//指定服务停止
-	if len(services) == 0 {
-		for serviceName := range dockerCompose.Services {
-			serviceDown(serviceName)
+	failed := false
+	removedContainers := 0
+	for i := len(levels) - 1; i >= 0; i-- {
+		removed, ok := levelDown(levels[i], stopTimeout)
+		removedContainers += removed
+		if !ok {
+			failed = true
 		}
-	} else {
-		//全部停止
-		for service, _ := range services {
-			_, exist := dockerCompose.Services[service]
-			if !exist {
-				fmt.Printf("Service %s does not exist\n", service)
-			} else {
-				serviceDown(service)
-			}
+	}
+
+	removedContainers += cleanOrphans(removeOrphans, stopTimeout)
+
+	//指定服务时只删除服务的容器，网络和卷可能还在被其它服务使用
+	var removedNetworks, removedVolumes, removedImages []string
+	if len(args) == 0 {
+		removedNetworks, err = compose.RemoveNetworks()
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
 		}
 	}
 
-	RemoveOrphans(removeOrphans)
+	if volumes && len(args) == 0 {
+		removedVolumes, err = compose.RemoveVolumes()
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+	}
+
+	if rmi != "" {
+		var serviceNames []string
+		for _, level := range levels {
+			serviceNames = append(serviceNames, level...)
+		}
+		removedImages, err = compose.RemoveImages(serviceNames, rmi)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+	}
 
+	fmt.Printf("Removed %s, %s, %s, %s\n",
+		plural(removedContainers, "container"),
+		plural(len(removedNetworks), "network"),
+		plural(len(removedVolumes), "volume"),
+		plural(len(removedImages), "image"))
+	if failed {
+		os.Exit(1)
+	}
 }
 
-// 删除孤立项
-func RemoveOrphans(removeOrphans bool) {
+func plural(count int, noun string) string {
+	if count == 1 {
+		return "1 " + noun
+	}
+	return fmt.Sprintf("%d %ss", count, noun)
+}
+
+// RemoveOrphans 删除孤立项
+func RemoveOrphans(remove bool) {
+	cleanOrphans(remove, nil)
+}
+
+// 返回删除的孤立容器数量
+func cleanOrphans(remove bool, stopTimeout *uint) int {
 	dockerCompose := compose.GetDockerCompose()
-	if removeOrphans {
-		for _, container := range compose.ContainerList {
-			expectServiceName := container.Labels[constant.LabelComposeServiceName]
-			_, exist := dockerCompose.Services[expectServiceName]
-			if !exist {
-				fmt.Print("orphans {" + expectServiceName + "} removing...")
-				force := true
-				cli.Remove(container.ID, &force, nil)
-				fmt.Print(util.TextColor(32, "down"))
-				fmt.Println()
-			}
+	var orphans []cli.ListContainer
+	for _, container := range compose.ContainerList {
+		expectServiceName := container.Labels[constant.LabelComposeServiceName]
+		if _, exist := dockerCompose.Services[expectServiceName]; !exist {
+			orphans = append(orphans, container)
 		}
-	} else {
-		existOrphans := false
-		for _, container := range compose.ContainerList {
-			expectServiceName := container.Labels[constant.LabelComposeServiceName]
-			_, exist := dockerCompose.Services[expectServiceName]
-			if !exist {
-				existOrphans = true
-				break
+	}
+	if len(orphans) == 0 {
+		return 0
+	}
+	if !remove {
+		fmt.Println("exist orphans, you can clean orphan containers with `--remove-orphans`")
+		return 0
+	}
+
+	//孤立容器的服务配置已经不存在，使用默认的停止方式
+	var wg sync.WaitGroup
+	var lock sync.Mutex
+	removed := 0
+	for _, container := range orphans {
+		wg.Add(1)
+		go func(container cli.ListContainer) {
+			defer wg.Done()
+			name := "orphans {" + container.Labels[constant.LabelComposeServiceName] + "}"
+			if stopAndRemove(name, container, compose.ServiceConfig{}, stopTimeout) {
+				lock.Lock()
+				removed++
+				lock.Unlock()
 			}
+		}(container)
+	}
+	wg.Wait()
+	return removed
+}
+
+/*
+*
+停止并删除同一层中所有服务的容器(包括 run 创建的一次性容器)，容器之间并行
+返回删除的容器数量，以及是否全部成功
+*/
+func levelDown(serviceNames []string, stopTimeout *uint) (int, bool) {
+	dockerCompose := compose.GetDockerCompose()
+	var wg sync.WaitGroup
+	var lock sync.Mutex
+	success := true
+	removed := 0
+	for _, serviceName := range serviceNames {
+		service := dockerCompose.Services[serviceName]
+		containers := compose.GetContainers(serviceName)
+		names := make([]string, 0, len(containers))
+		for _, container := range containers {
+			names = append(names, compose.FormatServiceName(compose.DisplayName(serviceName, compose.ContainerNumber(container))))
+		}
+		for _, container := range compose.GetOneOffContainers(serviceName) {
+			containers = append(containers, container)
+			names = append(names, container.Names[0])
 		}
-		if existOrphans {
-			fmt.Println("exist orphans, you can clean orphan containers with `--remove-orphans`")
+		for i, container := range containers {
+			wg.Add(1)
+			go func(name string, container cli.ListContainer) {
+				defer wg.Done()
+				ok := stopAndRemove(name, container, service, stopTimeout)
+				lock.Lock()
+				if ok {
+					removed++
+				} else {
+					success = false
+				}
+				lock.Unlock()
+			}(names[i], container)
 		}
 	}
+	wg.Wait()
+	return removed, success
 }
 
-func serviceDown(serviceName string) {
-	container, exist := compose.GetContainer(serviceName)
+/*
+*
+先按 stop_signal、stop_grace_period 停止容器，再删除
+停止失败时不删除容器，返回 false
+*/
+func stopAndRemove(name string, container cli.ListContainer, service compose.ServiceConfig, stopTimeout *uint) bool {
+	begin := time.Now()
+	if container.State == "running" || container.State == "paused" {
+		err := compose.StopContainer(service, container.ID, stopTimeout)
+		if err != nil {
+			fmt.Println(name + " stopping... " + util.TextColor(31, "error") + " " + err.Error())
+			return false
+		}
+		fmt.Println(name + " stopping... " + util.TextColor(32, "done") + " " + formatElapsed(begin))
+	}
 
-	if exist {
-		force := true
-		fmt.Print(compose.FormatServiceName(serviceName) + " removing...")
-		cli.Remove(container.ID, &force, nil)
-		fmt.Print(util.TextColor(32, "down"))
-		fmt.Println()
+	begin = time.Now()
+	force := true
+	err := cli.Remove(container.ID, &force, &volumes)
+	if err != nil {
+		fmt.Println(name + " removing... " + util.TextColor(31, "error") + " " + err.Error())
+		return false
 	}
+	fmt.Println(name + " removing... " + util.TextColor(32, "done") + " " + formatElapsed(begin))
+	return true
+}
+
+func formatElapsed(begin time.Time) string {
+	return fmt.Sprintf("(%.1fs)", time.Since(begin).Seconds())
 }
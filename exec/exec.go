@@ -0,0 +1,130 @@
+package exec
+
+import (
+	"fmt"
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+	"os"
+	"podman-compose/cli"
+	"podman-compose/compose"
+	"podman-compose/registry"
+	"podman-compose/util"
+	"strings"
+	"time"
+)
+
+var execCmd = &cobra.Command{
+	Use:   "exec [OPTIONS] SERVICE COMMAND [ARGS...]",
+	Short: "Execute a command in a running container",
+	Args:  cobra.MinimumNArgs(2),
+	Run:   exec,
+}
+
+// 不分配 TTY
+var noTTY = false
+var user = ""
+var workdir = ""
+var envs []string
+var privileged = false
+
+// 多副本时使用第几个容器
+var index = 1
+
+func init() {
+	execCmd.Flags().SetInterspersed(false)
+	execCmd.Flags().BoolVarP(&noTTY, "no-TTY", "T", false, "Disable pseudo-TTY allocation. By default `exec` allocates a TTY.")
+	execCmd.Flags().StringVarP(&user, "user", "u", "", "Run the command as this user")
+	execCmd.Flags().StringVarP(&workdir, "workdir", "w", "", "Path to workdir directory for this command")
+	execCmd.Flags().StringArrayVarP(&envs, "env", "e", nil, "Set environment variables")
+	execCmd.Flags().BoolVarP(&privileged, "privileged", "", false, "Give extended privileges to the process")
+	execCmd.Flags().IntVarP(&index, "index", "", 1, "Index of the container if service has multiple replicas")
+	registry.Commands = append(registry.Commands, execCmd)
+}
+
+func exec(cmd *cobra.Command, args []string) {
+	serviceName := args[0]
+	if _, exist := compose.GetDockerCompose().Services[serviceName]; !exist {
+		fmt.Printf("Service %s does not exist\n", serviceName)
+		os.Exit(1)
+	}
+	container, exist := compose.GetContainerByNumber(serviceName, index)
+	if !exist || container.State != "running" {
+		fmt.Printf("service %q is not running container #%d\n", serviceName, index)
+		os.Exit(1)
+	}
+
+	//只有输入输出都是终端时才分配 TTY
+	stdinFd := int(os.Stdin.Fd())
+	tty := !noTTY && term.IsTerminal(stdinFd) && term.IsTerminal(int(os.Stdout.Fd()))
+
+	config := cli.ExecCreateConfig{
+		AttachStdin:  true,
+		AttachStdout: true,
+		AttachStderr: true,
+		Tty:          tty,
+		Env:          formatEnv(envs),
+		Cmd:          args[1:],
+		Privileged:   privileged,
+		User:         user,
+		WorkingDir:   workdir,
+	}
+	sessionID, err := cli.ExecCreate(container.ID, config)
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+
+	//TTY 模式下终端切换到 raw 模式，由容器内的进程处理按键
+	started := func() {}
+	var state *term.State
+	if tty {
+		state, err = term.MakeRaw(stdinFd)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		started = func() {
+			go util.ResizeOnWinch(func(height, width int) {
+				cli.ExecResize(sessionID, height, width)
+			})
+		}
+	}
+
+	err = cli.ExecStartAndAttach(sessionID, tty, os.Stdin, os.Stdout, os.Stderr, started)
+	if state != nil {
+		term.Restore(stdinFd, state)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	os.Exit(exitCode(sessionID))
+}
+
+// -e KEY 没有值时使用当前环境变量的值
+func formatEnv(envs []string) []string {
+	var result []string
+	for _, env := range envs {
+		if strings.Contains(env, "=") {
+			result = append(result, env)
+		} else if value, ok := os.LookupEnv(env); ok {
+			result = append(result, env+"="+value)
+		}
+	}
+	return result
+}
+
+// 等待会话结束，返回命令的退出码
+func exitCode(sessionID string) int {
+	for {
+		inspect, err := cli.ExecInspect(sessionID)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		if !inspect.Running {
+			return inspect.ExitCode
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+}
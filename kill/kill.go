@@ -0,0 +1,54 @@
+package kill
+
+import (
+	"fmt"
+	"github.com/spf13/cobra"
+	"os"
+	"podman-compose/cli"
+	"podman-compose/compose"
+	"podman-compose/registry"
+	"podman-compose/util"
+)
+
+var killCmd = &cobra.Command{
+	Use:   "kill [SERVICE...]",
+	Short: "Force stop service containers",
+	Run:   kill,
+}
+
+var signal = "SIGKILL"
+
+func init() {
+	killCmd.Flags().StringVarP(&signal, "signal", "s", "SIGKILL", "SIGNAL to send to the container")
+	registry.Commands = append(registry.Commands, killCmd)
+}
+
+func kill(cmd *cobra.Command, args []string) {
+	levels, err := compose.GetStartOrder(args, false)
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+
+	failed := false
+	for _, level := range levels {
+		for _, serviceName := range level {
+			for _, container := range compose.GetContainers(serviceName) {
+				if container.State != "running" && container.State != "paused" {
+					continue
+				}
+				name := compose.DisplayName(serviceName, compose.ContainerNumber(container))
+				err = cli.Kill(container.ID, &signal)
+				if err != nil {
+					fmt.Println(name, ":", err)
+					failed = true
+					continue
+				}
+				fmt.Println(compose.FormatServiceName(name) + " killing... " + util.TextColor(32, "done"))
+			}
+		}
+	}
+	if failed {
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,123 @@
+package logs
+
+import (
+	"fmt"
+	"github.com/spf13/cobra"
+	"os"
+	"podman-compose/cli"
+	"podman-compose/compose"
+	"podman-compose/registry"
+	"podman-compose/util"
+	"sort"
+	"sync"
+)
+
+var logsCmd = &cobra.Command{
+	Use:   "logs [SERVICE...]",
+	Short: "View output from containers",
+	Run:   logs,
+}
+
+var follow = false
+var tail = "all"
+var since = ""
+var until = ""
+var timestamps = false
+var noColor = false
+
+func init() {
+	logsCmd.Flags().BoolVarP(&follow, "follow", "", false, "Follow log output")
+	logsCmd.Flags().StringVarP(&tail, "tail", "n", "all", "Number of lines to show from the end of the logs for each container")
+	logsCmd.Flags().StringVarP(&since, "since", "", "", "Show logs since timestamp (e.g. 2013-01-02T13:23:37Z) or relative (e.g. 42m for 42 minutes)")
+	logsCmd.Flags().StringVarP(&until, "until", "", "", "Show logs before a timestamp (e.g. 2013-01-02T13:23:37Z) or relative (e.g. 42m for 42 minutes)")
+	logsCmd.Flags().BoolVarP(&timestamps, "timestamps", "t", false, "Show timestamps")
+	logsCmd.Flags().BoolVarP(&noColor, "no-color", "", false, "Produce monochrome output")
+	registry.Commands = append(registry.Commands, logsCmd)
+}
+
+func logs(cmd *cobra.Command, args []string) {
+	names := args
+	if len(names) == 0 {
+		for name := range compose.GetDockerCompose().Services {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+	}
+
+	options := cli.LogOptions{Follow: &follow, Timestamps: &timestamps}
+	if tail != "all" {
+		options.Tail = &tail
+	}
+	if since != "" {
+		options.Since = &since
+	}
+	if until != "" {
+		options.Until = &until
+	}
+
+	var wg sync.WaitGroup
+	for _, name := range names {
+		if _, exist := compose.GetDockerCompose().Services[name]; !exist {
+			fmt.Printf("Service %s does not exist\n", name)
+			os.Exit(1)
+		}
+		for _, container := range compose.GetContainers(name) {
+			wg.Add(1)
+			go func(name string, number int, id string) {
+				defer wg.Done()
+				err := PrintLogs(name, number, id, options, noColor)
+				if err != nil {
+					fmt.Println(compose.DisplayName(name, number), ":", err)
+				}
+			}(name, compose.ContainerNumber(container), container.ID)
+		}
+	}
+	wg.Wait()
+}
+
+var printLock sync.Mutex
+
+// 服务名称使用的颜色
+var colors = []int{36, 33, 32, 35, 34, 96, 93, 92, 95, 94}
+
+// ServicePrefix 日志前缀，每个服务一种颜色，多副本的服务带上容器序号
+func ServicePrefix(serviceName string, number int, noColor bool) string {
+	prefix := compose.FormatServiceName(compose.DisplayName(serviceName, number)) + " | "
+	if noColor {
+		return prefix
+	}
+	var names []string
+	for name := range compose.GetDockerCompose().Services {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	idx := sort.SearchStrings(names, serviceName)
+	return util.TextColor(colors[idx%len(colors)], prefix)
+}
+
+/*
+*
+输出容器日志，每一行带上服务名称前缀
+*/
+func PrintLogs(serviceName string, number int, containerID string, options cli.LogOptions, noColor bool) error {
+	prefix := ServicePrefix(serviceName, number, noColor)
+	stdout := true
+	stderr := true
+	options.Stdout = &stdout
+	options.Stderr = &stderr
+
+	lines := make(chan string, 100)
+	done := make(chan struct{})
+	go func() {
+		for line := range lines {
+			printLock.Lock()
+			fmt.Println(prefix + line)
+			printLock.Unlock()
+		}
+		close(done)
+	}()
+	err := cli.Logs(containerID, options, lines, lines)
+	close(lines)
+	<-done
+	return err
+}
@@ -4,11 +4,24 @@ import (
 	"fmt"
 	"github.com/spf13/cobra"
 	"os"
+	_ "podman-compose/build"
 	"podman-compose/compose"
+	_ "podman-compose/config"
 	_ "podman-compose/down"
+	_ "podman-compose/exec"
+	_ "podman-compose/kill"
+	_ "podman-compose/logs"
+	_ "podman-compose/pause"
+	_ "podman-compose/plan"
 	_ "podman-compose/ps"
+	_ "podman-compose/pull"
 	"podman-compose/registry"
+	_ "podman-compose/restart"
+	_ "podman-compose/run"
+	_ "podman-compose/start"
 	"podman-compose/startup"
+	_ "podman-compose/stop"
+	_ "podman-compose/unpause"
 	_ "podman-compose/up"
 )
 
@@ -20,6 +33,19 @@ var rootCmd = &cobra.Command{
 Usage:
   docker-compose [-f <arg>...] [--profile <name>...] [options] [--] [COMMAND] [ARGS...]
   docker-compose -h|--help`,
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		//初始化Compose文件
+		return compose.InitCompose()
+	},
+	SilenceUsage:  true,
+	SilenceErrors: true,
+}
+
+func init() {
+	rootCmd.PersistentFlags().StringArrayVarP(&compose.ComposeFiles, "file", "f", nil, "Compose configuration files")
+	rootCmd.PersistentFlags().StringVarP(&compose.ProjectName, "project-name", "p", "", "Project name")
+	rootCmd.PersistentFlags().BoolVar(&compose.Strict, "strict", false, "Treat unknown keys in the compose file as errors")
+	rootCmd.PersistentFlags().StringArrayVar(&compose.EnvFiles, "env-file", nil, "Specify an alternate environment file")
 }
 
 func main() {
@@ -34,14 +60,7 @@ func main() {
 	for _, cmd := range registry.Commands {
 		rootCmd.AddCommand(cmd)
 	}
-	//初始化Compose文件
-	err := compose.InitCompose()
-	if err != nil {
-		fmt.Println(err)
-		return
-	}
-
-	if err = rootCmd.Execute(); err != nil {
+	if err := rootCmd.Execute(); err != nil {
 		fmt.Println(err)
 		os.Exit(1)
 	}
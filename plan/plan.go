@@ -0,0 +1,284 @@
+package plan
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/spf13/cobra"
+	"os"
+	"podman-compose/cli"
+	"podman-compose/compose"
+	"podman-compose/constant"
+	"podman-compose/registry"
+	"podman-compose/util"
+	"sort"
+	"strings"
+)
+
+var planCmd = &cobra.Command{
+	Use:   "plan [SERVICE...]",
+	Short: "Show which containers up would create, recreate or leave unchanged",
+	Run:   plan,
+}
+
+var format = "text"
+
+func init() {
+	planCmd.Flags().StringVarP(&format, "format", "", "text", "Format the output. Values: [text | json]")
+	registry.Commands = append(registry.Commands, planCmd)
+}
+
+const (
+	ActionCreate    = "create"
+	ActionRecreate  = "recreate"
+	ActionStart     = "start"
+	ActionUnchanged = "unchanged"
+	ActionRemove    = "remove"
+)
+
+// Options 影响重建决策的选项，对应 up 的参数
+type Options struct {
+	// 即使配置和镜像都没有变化也重建
+	ForceRecreate bool
+	// 已存在的容器不重建，未运行时直接启动
+	NoRecreate bool
+	// 依赖的服务创建或重建时，一起重建
+	AlwaysRecreateDeps bool
+}
+
+// ServiceAction up 对服务的单个容器要做的操作以及原因
+type ServiceAction struct {
+	Service     string   `json:"service"`
+	Number      int      `json:"number"`
+	Action      string   `json:"action"`
+	Container   string   `json:"container,omitempty"`
+	ContainerID string   `json:"-"`
+	Reasons     []string `json:"reasons,omitempty"`
+	// 配置中修改过的字段
+	Changes []string `json:"changes,omitempty"`
+}
+
+func plan(cmd *cobra.Command, args []string) {
+	levels, err := compose.GetStartOrder(args, true)
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+	actions, err := Compute(levels, Options{})
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+	err = Print(actions, format)
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+}
+
+/*
+*
+按启动顺序计算每个服务的操作，不会修改任何容器
+*/
+func Compute(levels [][]string, options Options) ([]ServiceAction, error) {
+	dockerCompose := compose.GetDockerCompose()
+	var actions []ServiceAction
+	recreated := map[string]bool{}
+	for _, level := range levels {
+		for _, serviceName := range level {
+			service := dockerCompose.Services[serviceName]
+			recreatedDeps, err := RecreatedDependencies(service, func(dep string) bool { return recreated[dep] })
+			if err != nil {
+				return nil, fmt.Errorf("%s: %v", serviceName, err)
+			}
+			serviceActions, err := ComputeService(serviceName, service, options, recreatedDeps)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %v", serviceName, err)
+			}
+			for _, action := range serviceActions {
+				if action.Action == ActionCreate || action.Action == ActionRecreate {
+					recreated[serviceName] = true
+				}
+			}
+			actions = append(actions, serviceActions...)
+		}
+	}
+	return actions, nil
+}
+
+// RecreatedDependencies 依赖的服务中被创建或重建的服务
+func RecreatedDependencies(service compose.ServiceConfig, recreated func(string) bool) ([]string, error) {
+	deps, err := service.GetDependsOn()
+	if err != nil {
+		return nil, err
+	}
+	var result []string
+	for dep := range deps {
+		if recreated(dep) {
+			result = append(result, dep)
+		}
+	}
+	sort.Strings(result)
+	return result, nil
+}
+
+/*
+*
+计算单个服务每个容器的操作
+按副本数逐个计算，序号超出副本数的容器删除
+*/
+func ComputeService(serviceName string, service compose.ServiceConfig, options Options, recreatedDeps []string) ([]ServiceAction, error) {
+	replicas := service.GetReplicas()
+	var actions []ServiceAction
+	for number := 1; number <= replicas; number++ {
+		container, exist := compose.GetContainerByNumber(serviceName, number)
+		var action ServiceAction
+		var err error
+		if exist {
+			action, err = computeContainer(serviceName, service, container, options, recreatedDeps)
+			if err != nil {
+				return nil, err
+			}
+		} else {
+			action = ServiceAction{
+				Service:   serviceName,
+				Action:    ActionCreate,
+				Container: compose.GetContainerName(serviceName, service, number),
+				Reasons:   []string{"container does not exist"},
+			}
+		}
+		action.Number = number
+		actions = append(actions, action)
+	}
+
+	for _, container := range compose.GetContainers(serviceName) {
+		number := compose.ContainerNumber(container)
+		if number <= replicas {
+			continue
+		}
+		action := ServiceAction{
+			Service:     serviceName,
+			Number:      number,
+			Action:      ActionRemove,
+			ContainerID: container.ID,
+			Reasons:     []string{fmt.Sprintf("scaled down to %d replicas", replicas)},
+		}
+		if len(container.Names) > 0 {
+			action.Container = container.Names[0]
+		}
+		actions = append(actions, action)
+	}
+	return actions, nil
+}
+
+/*
+*
+计算已存在的容器的操作
+容器未运行、配置哈希变化或者镜像 ID 变化时重建；否则不变
+recreatedDeps 为本次创建或重建的依赖服务，AlwaysRecreateDeps 时会导致重建
+*/
+func computeContainer(serviceName string, service compose.ServiceConfig, container cli.ListContainer, options Options, recreatedDeps []string) (ServiceAction, error) {
+	action := ServiceAction{Service: serviceName, ContainerID: container.ID}
+	if len(container.Names) > 0 {
+		action.Container = container.Names[0]
+	}
+
+	//不重建时，只需要启动未运行的容器
+	if options.NoRecreate {
+		if container.State == "running" {
+			action.Action = ActionUnchanged
+		} else {
+			action.Action = ActionStart
+			action.Reasons = []string{"container is not running (" + container.State + ")"}
+		}
+		return action, nil
+	}
+
+	if options.ForceRecreate {
+		action.Reasons = append(action.Reasons, "forced by --force-recreate")
+	}
+	if options.AlwaysRecreateDeps && len(recreatedDeps) > 0 {
+		action.Reasons = append(action.Reasons, "dependency "+strings.Join(recreatedDeps, ", ")+" recreated")
+	}
+	if container.State != "running" {
+		action.Reasons = append(action.Reasons, "container is not running ("+container.State+")")
+	}
+
+	//配置
+	match, err := service.MatchConfigKey(container.Labels)
+	if err != nil {
+		return action, err
+	}
+	if !match {
+		reason := "config hash changed"
+		oldHashes, recorded := container.Labels[constant.LabelConfigFields]
+		if recorded {
+			newHashes, err := service.GetFieldHashes()
+			if err != nil {
+				return action, err
+			}
+			action.Changes, err = compose.DiffFieldHashes(oldHashes, newHashes)
+			if err != nil {
+				return action, err
+			}
+		} else {
+			reason += " (previous config not recorded)"
+		}
+		action.Reasons = append(action.Reasons, reason)
+	}
+
+	//镜像
+	imageName := compose.GetImageName(serviceName, service)
+	image, err := cli.GetImage(imageName, nil)
+	if err != nil {
+		action.Reasons = append(action.Reasons, "image "+imageName+" not found locally")
+	} else {
+		detail, err := cli.Inspect(container.ID, nil)
+		if err != nil {
+			return action, err
+		}
+		if detail.Image != image.ID {
+			action.Reasons = append(action.Reasons, "image ID changed")
+		}
+	}
+
+	if len(action.Reasons) > 0 {
+		action.Action = ActionRecreate
+	} else {
+		action.Action = ActionUnchanged
+	}
+	return action, nil
+}
+
+// Print 输出计划，format 为 text 或 json
+func Print(actions []ServiceAction, format string) error {
+	switch format {
+	case "json":
+		if actions == nil {
+			actions = []ServiceAction{}
+		}
+		data, err := json.MarshalIndent(actions, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(data))
+	case "text":
+		for _, action := range actions {
+			fmt.Println(compose.FormatServiceName(compose.DisplayName(action.Service, action.Number)) + util.FixSizeString(action.Action, 10, false) + " " + action.Reason())
+		}
+	default:
+		return fmt.Errorf("unsupported format %q", format)
+	}
+	return nil
+}
+
+// Reason 原因的文字描述，修改过的字段跟在配置变化后面
+func (a ServiceAction) Reason() string {
+	var reasons []string
+	for _, reason := range a.Reasons {
+		if strings.HasPrefix(reason, "config hash changed") && len(a.Changes) > 0 {
+			reason += ": " + strings.Join(a.Changes, ", ")
+		}
+		reasons = append(reasons, reason)
+	}
+	return strings.Join(reasons, "; ")
+}
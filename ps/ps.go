@@ -3,6 +3,7 @@ package ps
 import (
 	"fmt"
 	"github.com/spf13/cobra"
+	"os"
 	"podman-compose/cli"
 	"podman-compose/compose"
 	"podman-compose/registry"
@@ -11,7 +12,7 @@ import (
 )
 
 var psCmd = &cobra.Command{
-	Use:   "ps",
+	Use:   "ps [SERVICE...]",
 	Short: "List containers.",
 	Run:   ps,
 }
@@ -28,9 +29,25 @@ func init() {
 
 func ps(cmd *cobra.Command, args []string) {
 	compose.InitContainerList()
+	containers := compose.ContainerList
+	if len(args) > 0 {
+		containers = nil
+		for _, serviceName := range args {
+			if _, exist := compose.GetDockerCompose().Services[serviceName]; !exist {
+				fmt.Printf("Service %s does not exist\n", serviceName)
+				os.Exit(1)
+			}
+			containers = append(containers, compose.GetContainers(serviceName)...)
+			containers = append(containers, compose.GetOneOffContainers(serviceName)...)
+		}
+	}
 	fmt.Println("   Name                   Command                State                         Ports                     ")
 	fmt.Println("---------------------------------------------------------------------------------------------------------")
-	for _, container := range compose.ContainerList {
+	for _, container := range containers {
+		//run 创建的一次性容器只在 -a 时显示
+		if compose.IsOneOff(container) && !all {
+			continue
+		}
 		if container.State == "running" {
 			fmt.Println(toString(container))
 		} else if all {
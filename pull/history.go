@@ -0,0 +1,64 @@
+package pull
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// 记录每个镜像最后一次拉取的时间，用于 daily、weekly、every_<duration> 策略
+var historyLock sync.Mutex
+
+func historyFile() (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "podman-compose", "pull-history.json"), nil
+}
+
+func readHistory() map[string]int64 {
+	history := map[string]int64{}
+	file, err := historyFile()
+	if err != nil {
+		return history
+	}
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return history
+	}
+	json.Unmarshal(data, &history)
+	return history
+}
+
+// 镜像最后一次拉取的时间，没有记录时返回零值
+func lastPulled(image string) time.Time {
+	historyLock.Lock()
+	defer historyLock.Unlock()
+	if pulled, exist := readHistory()[image]; exist {
+		return time.Unix(pulled, 0)
+	}
+	return time.Time{}
+}
+
+func recordPulled(image string) error {
+	historyLock.Lock()
+	defer historyLock.Unlock()
+	file, err := historyFile()
+	if err != nil {
+		return err
+	}
+	history := readHistory()
+	history[image] = time.Now().Unix()
+	data, err := json.Marshal(history)
+	if err != nil {
+		return err
+	}
+	err = os.MkdirAll(filepath.Dir(file), 0755)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(file, data, 0644)
+}
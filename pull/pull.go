@@ -0,0 +1,220 @@
+package pull
+
+import (
+	"fmt"
+	"github.com/spf13/cobra"
+	"io"
+	"os"
+	"podman-compose/build"
+	"podman-compose/cli"
+	"podman-compose/compose"
+	"podman-compose/registry"
+	"podman-compose/util"
+	"sort"
+	"sync"
+	"time"
+)
+
+var pullCmd = &cobra.Command{
+	Use:   "pull [SERVICE...]",
+	Short: "Pull service images",
+	Run:   pull,
+}
+
+var quiet = false
+var ignorePullFailures = false
+
+func init() {
+	pullCmd.Flags().BoolVarP(&quiet, "quiet", "q", false, "Pull without printing progress information")
+	pullCmd.Flags().BoolVarP(&ignorePullFailures, "ignore-pull-failures", "", false, "Pull what it can and ignores images with pull failures")
+	registry.Commands = append(registry.Commands, pullCmd)
+}
+
+func pull(cmd *cobra.Command, args []string) {
+	dockerCompose := compose.GetDockerCompose()
+	names := args
+	if len(names) == 0 {
+		for name := range dockerCompose.Services {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+	}
+
+	var wg sync.WaitGroup
+	var lock sync.Mutex
+	failed := false
+	for _, name := range names {
+		service, exist := dockerCompose.Services[name]
+		if !exist {
+			fmt.Printf("Service %s does not exist\n", name)
+			os.Exit(1)
+		}
+		//只有 build 的服务没有可以拉取的镜像
+		policy, _, _ := service.GetPullPolicy()
+		if service.Image == "" || policy == compose.PullPolicyBuild {
+			if !quiet {
+				fmt.Println(compose.FormatServiceName(name) + " uses a build context, skipping")
+			}
+			continue
+		}
+		wg.Add(1)
+		go func(name string, service compose.ServiceConfig) {
+			defer wg.Done()
+			err := PullService(name, service, quiet)
+			if err == nil {
+				return
+			}
+			//可以构建的服务拉取失败不影响结果
+			if ignorePullFailures || service.Build != nil {
+				fmt.Println(compose.FormatServiceName(name)+" pull failed, ignoring:", err)
+				return
+			}
+			fmt.Println(name, ":", err)
+			lock.Lock()
+			failed = true
+			lock.Unlock()
+		}(name, service)
+	}
+	wg.Wait()
+	if failed {
+		os.Exit(1)
+	}
+}
+
+/*
+*
+拉取服务的镜像，拉取进度带上服务名称前缀
+*/
+func PullService(serviceName string, service compose.ServiceConfig, quiet bool) error {
+	image := compose.GetImageName(serviceName, service)
+	var progress io.Writer
+	if !quiet {
+		fmt.Println(compose.FormatServiceName(serviceName) + " pulling " + image + "...")
+		progress = &util.PrefixWriter{Prefix: compose.FormatServiceName(serviceName) + " | "}
+	}
+	_, err := cli.Pull(image, &quiet, progress)
+	if err != nil {
+		return err
+	}
+	err = recordPulled(image)
+	if err != nil {
+		fmt.Println(compose.FormatServiceName(serviceName)+" failed to record pull time:", err)
+	}
+	if !quiet {
+		fmt.Println(compose.FormatServiceName(serviceName) + " pulling " + image + "... " + util.TextColor(32, "done"))
+	}
+	return nil
+}
+
+const (
+	actionNone = iota
+	actionPull
+	actionBuild
+)
+
+/*
+*
+按 pull_policy 准备服务的镜像，需要拉取的镜像并行拉取，需要构建的镜像依次构建
+policy 不为空时覆盖服务自身的 pull_policy (up --pull)
+*/
+func EnsureImages(serviceNames []string, policy string) error {
+	dockerCompose := compose.GetDockerCompose()
+	var pulls, builds []string
+	for _, serviceName := range serviceNames {
+		action, err := imageAction(serviceName, dockerCompose.Services[serviceName], policy)
+		if err != nil {
+			return fmt.Errorf("%s: %v", serviceName, err)
+		}
+		switch action {
+		case actionPull:
+			pulls = append(pulls, serviceName)
+		case actionBuild:
+			builds = append(builds, serviceName)
+		}
+	}
+
+	var wg sync.WaitGroup
+	var lock sync.Mutex
+	var pullErr error
+	for _, serviceName := range pulls {
+		wg.Add(1)
+		go func(serviceName string, service compose.ServiceConfig) {
+			defer wg.Done()
+			err := PullService(serviceName, service, false)
+			if err == nil {
+				return
+			}
+			lock.Lock()
+			defer lock.Unlock()
+			//拉取失败时，可以构建的服务改为构建
+			if service.Build != nil {
+				fmt.Println(compose.FormatServiceName(serviceName)+" pull failed, building instead:", err)
+				builds = append(builds, serviceName)
+				return
+			}
+			if pullErr == nil {
+				pullErr = fmt.Errorf("%s: %v", serviceName, err)
+			}
+		}(serviceName, dockerCompose.Services[serviceName])
+	}
+	wg.Wait()
+	if pullErr != nil {
+		return pullErr
+	}
+
+	sort.Strings(builds)
+	for _, serviceName := range builds {
+		err := build.BuildService(serviceName, dockerCompose.Services[serviceName], false, false)
+		if err != nil {
+			return fmt.Errorf("%s: %v", serviceName, err)
+		}
+	}
+	return nil
+}
+
+// 根据拉取策略以及本地镜像是否存在，决定拉取、构建还是不处理
+func imageAction(serviceName string, service compose.ServiceConfig, override string) (int, error) {
+	policy, interval, err := service.GetPullPolicy()
+	if err != nil {
+		return actionNone, err
+	}
+	if override != "" {
+		policy, interval = override, 0
+	}
+	image := compose.GetImageName(serviceName, service)
+	exist, err := cli.ImageExists(image)
+	if err != nil {
+		return actionNone, err
+	}
+
+	switch policy {
+	case compose.PullPolicyNever:
+		if !exist {
+			return actionNone, fmt.Errorf("image %s not found locally and pull policy is never", image)
+		}
+		return actionNone, nil
+	case compose.PullPolicyBuild:
+		if service.Build == nil {
+			return actionNone, fmt.Errorf("pull policy is build but no build context specified")
+		}
+		return actionBuild, nil
+	case compose.PullPolicyAlways:
+		if service.Image != "" {
+			return actionPull, nil
+		}
+	case compose.PullPolicyMissing:
+		if exist && (interval == 0 || time.Since(lastPulled(image)) < interval) {
+			return actionNone, nil
+		}
+		if service.Image != "" {
+			return actionPull, nil
+		}
+	default:
+		return actionNone, fmt.Errorf("pull policy [%s] is Invalid", policy)
+	}
+	//没有 image 只有 build 的服务，镜像不存在时构建
+	if exist {
+		return actionNone, nil
+	}
+	return actionBuild, nil
+}
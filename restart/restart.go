@@ -0,0 +1,59 @@
+package restart
+
+import (
+	"fmt"
+	"github.com/spf13/cobra"
+	"os"
+	"podman-compose/compose"
+	"podman-compose/registry"
+	"podman-compose/util"
+)
+
+var restartCmd = &cobra.Command{
+	Use:   "restart [SERVICE...]",
+	Short: "Restart service containers",
+	Run:   restart,
+}
+
+// 等待容器停止的秒数，没有指定时使用服务的 stop_grace_period
+var timeout uint = 10
+
+func init() {
+	restartCmd.Flags().UintVarP(&timeout, "timeout", "t", 10, "Specify a shutdown timeout in seconds")
+	registry.Commands = append(registry.Commands, restartCmd)
+}
+
+func restart(cmd *cobra.Command, args []string) {
+	//按依赖关系的顺序重启，先重启被依赖的服务
+	levels, err := compose.GetStartOrder(args, false)
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+
+	var restartTimeout *uint
+	if cmd.Flags().Changed("timeout") {
+		restartTimeout = &timeout
+	}
+
+	dockerCompose := compose.GetDockerCompose()
+	failed := false
+	for _, level := range levels {
+		for _, serviceName := range level {
+			service := dockerCompose.Services[serviceName]
+			for _, container := range compose.GetContainers(serviceName) {
+				name := compose.DisplayName(serviceName, compose.ContainerNumber(container))
+				err = compose.RestartContainer(service, container.ID, restartTimeout)
+				if err != nil {
+					fmt.Println(name, ":", err)
+					failed = true
+					continue
+				}
+				fmt.Println(compose.FormatServiceName(name) + " restarting... " + util.TextColor(32, "done"))
+			}
+		}
+	}
+	if failed {
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,192 @@
+package run
+
+import (
+	"fmt"
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+	"os"
+	"podman-compose/cli"
+	"podman-compose/compose"
+	"podman-compose/pull"
+	"podman-compose/registry"
+	"podman-compose/up"
+	"podman-compose/util"
+)
+
+var runCmd = &cobra.Command{
+	Use:   "run [OPTIONS] SERVICE [COMMAND] [ARGS...]",
+	Short: "Run a one-off command on a service",
+	Args:  cobra.MinimumNArgs(1),
+	Run:   run,
+}
+
+// 退出后删除容器
+var rm = false
+var detach = false
+var name = ""
+var envs []string
+var volumes []string
+var publish []string
+
+// 发布服务中配置的端口
+var servicePorts = false
+var noDeps = false
+var entrypoint = ""
+var user = ""
+var workdir = ""
+
+// 不分配 TTY
+var noTTY = false
+
+func init() {
+	runCmd.Flags().SetInterspersed(false)
+	runCmd.Flags().BoolVarP(&rm, "rm", "", false, "Automatically remove the container when it exits")
+	runCmd.Flags().BoolVarP(&detach, "detach", "d", false, "Run container in background and print container name")
+	runCmd.Flags().StringVarP(&name, "name", "", "", "Assign a name to the container")
+	runCmd.Flags().StringArrayVarP(&envs, "env", "e", nil, "Set environment variables")
+	runCmd.Flags().StringArrayVarP(&volumes, "volume", "v", nil, "Bind mount a volume")
+	//-p 已经被项目名称占用
+	runCmd.Flags().StringArrayVarP(&publish, "publish", "", nil, "Publish a container's port(s) to the host")
+	runCmd.Flags().BoolVarP(&servicePorts, "service-ports", "", false, "Run command with the service's ports enabled and mapped to the host")
+	runCmd.Flags().BoolVarP(&noDeps, "no-deps", "", false, "Don't start linked services")
+	runCmd.Flags().StringVarP(&entrypoint, "entrypoint", "", "", "Override the entrypoint of the image")
+	runCmd.Flags().StringVarP(&user, "user", "u", "", "Run as specified username or uid")
+	runCmd.Flags().StringVarP(&workdir, "workdir", "w", "", "Working directory inside the container")
+	runCmd.Flags().BoolVarP(&noTTY, "no-TTY", "T", false, "Disable pseudo-TTY allocation. By default `run` allocates a TTY.")
+	registry.Commands = append(registry.Commands, runCmd)
+}
+
+func run(cmd *cobra.Command, args []string) {
+	serviceName := args[0]
+	service, exist := compose.GetDockerCompose().Services[serviceName]
+	if !exist {
+		fmt.Printf("Service %s does not exist\n", serviceName)
+		os.Exit(1)
+	}
+	options := compose.RunOptions{
+		Name:         name,
+		Command:      args[1:],
+		Env:          envs,
+		Volumes:      volumes,
+		Ports:        publish,
+		ServicePorts: servicePorts,
+		User:         user,
+		WorkDir:      workdir,
+	}
+	if cmd.Flags().Changed("entrypoint") {
+		var err error
+		options.Entrypoint, err = util.SplitCommand(entrypoint)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+	}
+
+	err := compose.EnsureVolumes()
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+	if !noDeps {
+		err = up.StartDependencies(serviceName)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+	}
+	err = compose.EnsureNetworks([]string{serviceName})
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+	err = pull.EnsureImages([]string{serviceName}, "")
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+
+	//network_mode: service:X 需要依赖的容器已经启动，所以在启动依赖之后生成参数
+	spec, err := compose.GetRunSpec(serviceName, service, options)
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+
+	//只有输入输出都是终端时才分配 TTY
+	stdinFd := int(os.Stdin.Fd())
+	tty := !detach && !noTTY && term.IsTerminal(stdinFd) && term.IsTerminal(int(os.Stdout.Fd()))
+	spec.Terminal = tty
+	spec.Stdin = !detach
+	//后台运行时由 podman 在容器退出后删除
+	spec.Remove = detach && rm
+
+	created, err := cli.CreateContainer(spec)
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+
+	if detach {
+		err = cli.Start(created.ID, nil)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		fmt.Println(spec.Name)
+		return
+	}
+
+	os.Exit(attachAndWait(created.ID, tty))
+}
+
+/*
+*
+附加到容器后再启动，容器退出后返回容器的退出码
+--rm 时删除容器以及匿名卷
+*/
+func attachAndWait(containerID string, tty bool) int {
+	stdinFd := int(os.Stdin.Fd())
+	var state *term.State
+	if tty {
+		var err error
+		state, err = term.MakeRaw(stdinFd)
+		if err != nil {
+			fmt.Println(err)
+			return 1
+		}
+	}
+
+	err := cli.Attach(containerID, tty, os.Stdin, os.Stdout, os.Stderr, func() error {
+		err := cli.Start(containerID, nil)
+		if err == nil && tty {
+			go util.ResizeOnWinch(func(height, width int) {
+				cli.ResizeContainerTTY(containerID, height, width)
+			})
+		}
+		return err
+	})
+	if state != nil {
+		term.Restore(stdinFd, state)
+	}
+
+	exitCode := 1
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+	} else {
+		code, err := cli.Wait(containerID, nil)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+		} else {
+			exitCode = int(code)
+		}
+	}
+
+	if rm {
+		force := true
+		err = cli.Remove(containerID, &force, &force)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+		}
+	}
+	return exitCode
+}
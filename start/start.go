@@ -0,0 +1,58 @@
+package start
+
+import (
+	"fmt"
+	"github.com/spf13/cobra"
+	"os"
+	"podman-compose/cli"
+	"podman-compose/compose"
+	"podman-compose/registry"
+	"podman-compose/util"
+)
+
+var startCmd = &cobra.Command{
+	Use:   "start [SERVICE...]",
+	Short: "Start existing containers for services",
+	Run:   start,
+}
+
+func init() {
+	registry.Commands = append(registry.Commands, startCmd)
+}
+
+func start(cmd *cobra.Command, args []string) {
+	//按依赖关系的顺序启动，先启动被依赖的服务
+	levels, err := compose.GetStartOrder(args, false)
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+
+	failed := false
+	for _, level := range levels {
+		for _, serviceName := range level {
+			containers := compose.GetContainers(serviceName)
+			if len(containers) == 0 {
+				fmt.Printf("service %q has no container to start\n", serviceName)
+				failed = true
+				continue
+			}
+			for _, container := range containers {
+				if container.State == "running" {
+					continue
+				}
+				name := compose.DisplayName(serviceName, compose.ContainerNumber(container))
+				err = cli.Start(container.ID, nil)
+				if err != nil {
+					fmt.Println(name, ":", err)
+					failed = true
+					continue
+				}
+				fmt.Println(compose.FormatServiceName(name) + " starting... " + util.TextColor(32, "done"))
+			}
+		}
+	}
+	if failed {
+		os.Exit(1)
+	}
+}
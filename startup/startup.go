@@ -5,6 +5,7 @@ import (
 	"os"
 	"os/exec"
 	"podman-compose/cli"
+	"podman-compose/constant"
 	"podman-compose/util"
 	"time"
 )
@@ -35,8 +36,8 @@ func StartUp() {
 				//启动
 				cli.Start(container.ID, nil)
 
-				//重新加载网络
-				{
+				//重新加载网络，项目自己管理的网络不需要
+				if !isProjectNetworked(container) {
 					podmanCmd, err := exec.LookPath("podman")
 					if err != nil {
 						fmt.Println(err)
@@ -53,6 +54,19 @@ func StartUp() {
 	}
 }
 
+// 是否只加入了项目创建的网络
+func isProjectNetworked(container cli.ListContainer) bool {
+	if _, ok := container.Labels[constant.LabelComposeProject]; !ok || len(container.Networks) == 0 {
+		return false
+	}
+	for _, network := range container.Networks {
+		if network == "podman" {
+			return false
+		}
+	}
+	return true
+}
+
 func getReloadCommand(container cli.ListContainer) []string {
 	return []string{"network", "reload", container.ID}
 }
@@ -0,0 +1,71 @@
+package stop
+
+import (
+	"fmt"
+	"github.com/spf13/cobra"
+	"os"
+	"podman-compose/compose"
+	"podman-compose/registry"
+	"podman-compose/util"
+	"sync"
+)
+
+var stopCmd = &cobra.Command{
+	Use:   "stop [SERVICE...]",
+	Short: "Stop services",
+	Run:   stop,
+}
+
+// 等待容器停止的秒数，没有指定时使用服务的 stop_grace_period
+var timeout uint = 10
+
+func init() {
+	stopCmd.Flags().UintVarP(&timeout, "timeout", "t", 10, "Specify a shutdown timeout in seconds")
+	registry.Commands = append(registry.Commands, stopCmd)
+}
+
+func stop(cmd *cobra.Command, args []string) {
+	levels, err := compose.GetStartOrder(args, false)
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+	var stopTimeout *uint
+	if cmd.Flags().Changed("timeout") {
+		stopTimeout = &timeout
+	}
+
+	//按依赖关系的逆序停止，同一层内并行
+	dockerCompose := compose.GetDockerCompose()
+	failed := false
+	var lock sync.Mutex
+	for i := len(levels) - 1; i >= 0; i-- {
+		var wg sync.WaitGroup
+		for _, serviceName := range levels[i] {
+			service := dockerCompose.Services[serviceName]
+			for _, container := range compose.GetContainers(serviceName) {
+				if container.State != "running" && container.State != "paused" {
+					continue
+				}
+				wg.Add(1)
+				go func(serviceName, containerID string, number int) {
+					defer wg.Done()
+					name := compose.DisplayName(serviceName, number)
+					err := compose.StopContainer(service, containerID, stopTimeout)
+					if err != nil {
+						fmt.Println(name, ":", err)
+						lock.Lock()
+						failed = true
+						lock.Unlock()
+						return
+					}
+					fmt.Println(compose.FormatServiceName(name) + " stopping... " + util.TextColor(32, "done"))
+				}(serviceName, container.ID, compose.ContainerNumber(container))
+			}
+		}
+		wg.Wait()
+	}
+	if failed {
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,51 @@
+package unpause
+
+import (
+	"fmt"
+	"github.com/spf13/cobra"
+	"os"
+	"podman-compose/cli"
+	"podman-compose/compose"
+	"podman-compose/registry"
+	"podman-compose/util"
+)
+
+var unpauseCmd = &cobra.Command{
+	Use:   "unpause [SERVICE...]",
+	Short: "Unpause services",
+	Run:   unpause,
+}
+
+func init() {
+	registry.Commands = append(registry.Commands, unpauseCmd)
+}
+
+func unpause(cmd *cobra.Command, args []string) {
+	levels, err := compose.GetStartOrder(args, false)
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+
+	failed := false
+	for _, level := range levels {
+		for _, serviceName := range level {
+			for _, container := range compose.GetContainers(serviceName) {
+				if container.State != "paused" {
+					continue
+				}
+				name := compose.DisplayName(serviceName, compose.ContainerNumber(container))
+				err = cli.Unpause(container.ID)
+				if err != nil {
+					fmt.Println(name, ":", err)
+					failed = true
+					continue
+				}
+				fmt.Println(compose.FormatServiceName(name) + " unpausing... " + util.TextColor(32, "done"))
+			}
+		}
+	}
+	if failed {
+		os.Exit(1)
+	}
+}
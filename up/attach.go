@@ -0,0 +1,121 @@
+package up
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"podman-compose/cli"
+	"podman-compose/compose"
+	"podman-compose/logs"
+	"podman-compose/util"
+	"sync"
+	"syscall"
+)
+
+// 容器退出事件
+type containerExit struct {
+	serviceName string
+	number      int
+	exitCode    int32
+}
+
+type attachedContainer struct {
+	serviceName string
+	number      int
+	id          string
+}
+
+/*
+*
+附加到所有服务的日志，Ctrl-C 时停止所有容器，再次 Ctrl-C 强制结束
+返回进程的退出码
+*/
+func attach(serviceNames []string) int {
+	compose.RefreshContainerList()
+	var containers []attachedContainer
+	for _, serviceName := range serviceNames {
+		for _, container := range compose.GetContainers(serviceName) {
+			containers = append(containers, attachedContainer{serviceName, compose.ContainerNumber(container), container.ID})
+		}
+	}
+	if len(containers) == 0 {
+		return 0
+	}
+
+	signals := make(chan os.Signal, 2)
+	signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(signals)
+
+	exited := make(chan containerExit, len(containers))
+	follow := true
+	for _, container := range containers {
+		go func(container attachedContainer) {
+			err := logs.PrintLogs(container.serviceName, container.number, container.id, cli.LogOptions{Follow: &follow}, false)
+			if err != nil {
+				fmt.Println(compose.DisplayName(container.serviceName, container.number), ":", err)
+			}
+			//日志结束说明容器已经退出
+			exitCode, err := cli.Wait(container.id, nil)
+			if err != nil {
+				exitCode = -1
+			}
+			exited <- containerExit{container.serviceName, container.number, exitCode}
+		}(container)
+	}
+
+	var stopping bool
+	var exitCode int
+	exitCodeSet := false
+	for remaining := len(containers); remaining > 0; {
+		select {
+		case <-signals:
+			if stopping {
+				fmt.Println("Killing...")
+				killContainers(containers)
+				return 130
+			}
+			stopping = true
+			fmt.Println("Gracefully stopping... (press Ctrl+C again to force)")
+			go stopContainers(containers)
+		case event := <-exited:
+			remaining--
+			fmt.Println(logs.ServicePrefix(event.serviceName, event.number, false) + fmt.Sprintf("exited with code %d", event.exitCode))
+			if exitCodeFrom == event.serviceName || (exitCodeFrom == "" && abortOnContainerExit && !exitCodeSet) {
+				exitCode = int(event.exitCode)
+				exitCodeSet = true
+			}
+			if abortOnContainerExit && !stopping {
+				stopping = true
+				fmt.Println("Aborting on container exit...")
+				go stopContainers(containers)
+			}
+		}
+	}
+	return exitCode
+}
+
+// 按 stop_signal、stop_grace_period 并行停止所有容器
+func stopContainers(containers []attachedContainer) {
+	dockerCompose := compose.GetDockerCompose()
+	var wg sync.WaitGroup
+	for _, container := range containers {
+		wg.Add(1)
+		go func(container attachedContainer) {
+			defer wg.Done()
+			err := compose.StopContainer(dockerCompose.Services[container.serviceName], container.id, nil)
+			if err != nil {
+				fmt.Println(compose.DisplayName(container.serviceName, container.number), ":", err)
+				return
+			}
+			fmt.Println(compose.FormatServiceName(compose.DisplayName(container.serviceName, container.number)) + " stopping... " + util.TextColor(32, "done"))
+		}(container)
+	}
+	wg.Wait()
+}
+
+func killContainers(containers []attachedContainer) {
+	sig := "SIGKILL"
+	for _, container := range containers {
+		cli.Kill(container.id, &sig)
+	}
+}
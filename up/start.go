@@ -0,0 +1,63 @@
+package up
+
+import (
+	"fmt"
+	"podman-compose/compose"
+	"podman-compose/pull"
+)
+
+/*
+*
+以 detach 模式启动服务依赖的服务(不包括服务本身)，并等待满足 depends_on 中的条件
+run 命令启动一次性容器前使用
+*/
+func StartDependencies(serviceName string) error {
+	levels, err := compose.GetStartOrder([]string{serviceName}, true)
+	if err != nil {
+		return err
+	}
+	dockerCompose := compose.GetDockerCompose()
+	selected := map[string]bool{}
+	var serviceNames []string
+	for _, level := range levels {
+		for _, name := range level {
+			if name != serviceName {
+				selected[name] = true
+				serviceNames = append(serviceNames, name)
+			}
+		}
+	}
+	if len(serviceNames) == 0 {
+		return nil
+	}
+
+	err = compose.EnsureNetworks(serviceNames)
+	if err != nil {
+		return err
+	}
+	err = pull.EnsureImages(serviceNames, "")
+	if err != nil {
+		return err
+	}
+
+	prepareServices(serviceNames)
+	channel := make(chan int, len(serviceNames))
+	for _, level := range levels {
+		for _, name := range level {
+			if !selected[name] {
+				continue
+			}
+			service := dockerCompose.Services[name]
+			err = waitForDependencies(name, service, selected)
+			if err != nil {
+				return err
+			}
+			serviceUp(name, service, channel)
+			<-channel
+			if _, failed := failedServices.Load(name); failed {
+				return fmt.Errorf("service %s failed to start", name)
+			}
+		}
+	}
+	return waitForDependencies(serviceName, dockerCompose.Services[serviceName], selected)
+}
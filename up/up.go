@@ -5,15 +5,18 @@ import (
 	"fmt"
 	"github.com/spf13/cobra"
 	"os"
-	"os/exec"
+	"podman-compose/build"
 	"podman-compose/cli"
 	"podman-compose/compose"
-	"podman-compose/constant"
 	"podman-compose/down"
+	"podman-compose/plan"
+	"podman-compose/pull"
 	"podman-compose/registry"
 	"podman-compose/util"
 	"strconv"
 	"strings"
+	"sync"
+	"time"
 )
 
 var upCmd = &cobra.Command{
@@ -27,57 +30,196 @@ var detach = false
 // 删除孤立项
 var removeOrphans = false
 
+// 任意容器退出时停止所有容器
+var abortOnContainerExit = false
+
+// 使用该服务容器的退出码作为返回值
+var exitCodeFrom = ""
+
+// 启动前构建镜像
+var buildImages = false
+
+// 拉取镜像的策略，policy 表示使用服务自身的 pull_policy
+var pullPolicy = "policy"
+
+// 只输出要做的操作，不修改任何容器
+var dryRun = false
+
+// --dry-run 的输出格式
+var format = "text"
+
+// 重建相关的选项
+var planOptions plan.Options
+
+// 只创建容器，不启动
+var noStart = false
+
+// 不启动依赖的服务
+var noDeps = false
+
+// 服务的副本数 SERVICE=NUM，覆盖配置中的 deploy.replicas
+var scale []string
+
+// 等待服务 running/healthy
+var wait = false
+var waitTimeout = 0
+
 func init() {
 	upCmd.Flags().BoolVarP(&detach, "detach", "d", false, "daemon mode")
+	upCmd.Flags().BoolVarP(&buildImages, "build", "", false, "Build images before starting containers")
+	upCmd.Flags().StringVarP(&pullPolicy, "pull", "", "policy", `Pull image before running ("always"|"missing"|"never"|"build"|"policy")`)
+	upCmd.Flags().BoolVarP(&dryRun, "dry-run", "", false, "Show what would be created, recreated or left unchanged without changing anything")
+	upCmd.Flags().StringVarP(&format, "format", "", "text", "Format the output of --dry-run. Values: [text | json]")
+	upCmd.Flags().BoolVarP(&planOptions.ForceRecreate, "force-recreate", "", false, "Recreate containers even if their configuration and image haven't changed")
+	upCmd.Flags().BoolVarP(&planOptions.NoRecreate, "no-recreate", "", false, "If containers already exist, don't recreate them. Incompatible with --force-recreate")
+	upCmd.Flags().BoolVarP(&planOptions.AlwaysRecreateDeps, "always-recreate-deps", "", false, "Recreate dependent containers. Incompatible with --no-recreate")
+	upCmd.Flags().BoolVarP(&noStart, "no-start", "", false, "Don't start the services after creating them")
+	upCmd.Flags().BoolVarP(&noDeps, "no-deps", "", false, "Don't start linked services")
+	upCmd.Flags().StringArrayVarP(&scale, "scale", "", nil, "Scale SERVICE to NUM instances. Overrides the `scale` setting in the Compose file if present.")
+	upCmd.Flags().BoolVarP(&wait, "wait", "", false, "Wait for services to be running|healthy. Implies detached mode.")
+	upCmd.Flags().IntVarP(&waitTimeout, "wait-timeout", "", 0, "Maximum duration in seconds to wait for the project to be running|healthy")
+	upCmd.Flags().BoolVarP(&abortOnContainerExit, "abort-on-container-exit", "", false, "Stops all containers if any container was stopped. Incompatible with -d")
+	upCmd.Flags().StringVarP(&exitCodeFrom, "exit-code-from", "", "", "Return the exit code of the selected service container. Implies --abort-on-container-exit")
 	upCmd.Flags().BoolVarP(&removeOrphans, "remove-orphans", "", false, "Remove containers for services not defined in the Compose file")
 	registry.Commands = append(registry.Commands, upCmd)
 }
 
 func up(cmd *cobra.Command, args []string) {
-	var err error
+	if wait {
+		detach = true
+	}
+	if detach && (abortOnContainerExit || exitCodeFrom != "") {
+		fmt.Println("--abort-on-container-exit and --exit-code-from are incompatible with --detach")
+		os.Exit(1)
+	}
+	if exitCodeFrom != "" {
+		if _, exist := compose.GetDockerCompose().Services[exitCodeFrom]; !exist {
+			fmt.Printf("Service %s does not exist\n", exitCodeFrom)
+			os.Exit(1)
+		}
+		abortOnContainerExit = true
+	}
+	if planOptions.NoRecreate && (planOptions.ForceRecreate || planOptions.AlwaysRecreateDeps) {
+		fmt.Println("--no-recreate is incompatible with --force-recreate and --always-recreate-deps")
+		os.Exit(1)
+	}
+	if noStart && (wait || abortOnContainerExit) {
+		fmt.Println("--no-start is incompatible with --wait, --abort-on-container-exit and --exit-code-from")
+		os.Exit(1)
+	}
+	for _, item := range scale {
+		serviceName, value, _ := strings.Cut(item, "=")
+		replicas, err := strconv.Atoi(value)
+		if err != nil || replicas < 0 {
+			fmt.Printf("invalid --scale option %s\n", item)
+			os.Exit(1)
+		}
+		err = compose.SetReplicas(serviceName, replicas)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+	}
+	if format != "text" && format != "json" {
+		fmt.Printf("invalid --format option %s\n", format)
+		os.Exit(1)
+	}
+	switch pullPolicy {
+	case "policy":
+		pullPolicy = ""
+	case compose.PullPolicyAlways, compose.PullPolicyMissing, compose.PullPolicyNever, compose.PullPolicyBuild:
+	default:
+		fmt.Printf("invalid --pull option %s\n", pullPolicy)
+		os.Exit(1)
+	}
+
+	//按依赖关系分层，依赖的服务会先启动
+	levels, err := compose.GetStartOrder(args, !noDeps)
 	if err != nil {
 		fmt.Println(err)
 		os.Exit(1)
 	}
 
-	services := map[string]string{}
-	for _, arg := range args {
-		services[arg] = "1"
+	if dryRun {
+		actions, err := plan.Compute(levels, planOptions)
+		if err == nil {
+			err = plan.Print(actions, format)
+		}
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		return
 	}
 
 	dockerCompose := compose.GetDockerCompose()
 
+	//创建声明的卷
+	err = compose.EnsureVolumes()
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+
+	//创建服务使用的网络
+	var serviceNames []string
+	for _, level := range levels {
+		serviceNames = append(serviceNames, level...)
+	}
+	err = compose.EnsureNetworks(serviceNames)
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+
+	//构建镜像，其余服务按拉取策略准备镜像
+	var imageServices []string
+	for _, serviceName := range serviceNames {
+		service := dockerCompose.Services[serviceName]
+		if !buildImages || service.Build == nil {
+			imageServices = append(imageServices, serviceName)
+			continue
+		}
+		err = build.BuildService(serviceName, service, false, false)
+		if err != nil {
+			fmt.Println(serviceName, ":", err)
+			os.Exit(1)
+		}
+	}
+	err = pull.EnsureImages(imageServices, pullPolicy)
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+
 	//先统计服务总数
 	//如果是 非 detach 模式， 则异步一起启动
 	var serviceNum int
-	var channel chan int
-	if len(services) == 0 {
-		serviceNum = len(dockerCompose.Services)
-	} else {
-		serviceNum = len(services)
-	}
-	channel = make(chan int, serviceNum)
-
-	if len(services) == 0 {
-		for serviceName := range dockerCompose.Services {
-			if detach {
-				serviceUp(serviceName, dockerCompose.Services[serviceName], channel)
-			} else {
-				go serviceUp(serviceName, dockerCompose.Services[serviceName], channel)
+	for _, level := range levels {
+		serviceNum += len(level)
+	}
+	channel := make(chan int, serviceNum)
+	selected := map[string]bool{}
+	for _, serviceName := range serviceNames {
+		selected[serviceName] = true
+	}
+	prepareServices(serviceNames)
+
+	for _, level := range levels {
+		for _, serviceName := range level {
+			serviceConfig := dockerCompose.Services[serviceName]
+			//等待依赖的服务满足条件，不启动时无需等待
+			if !noStart {
+				err = waitForDependencies(serviceName, serviceConfig, selected)
+				if err != nil {
+					fmt.Println(err)
+					os.Exit(1)
+				}
 			}
-		}
-	} else {
-		for service, _ := range services {
-			serviceConfig, exist := dockerCompose.Services[service]
-			if !exist {
-				fmt.Printf("Service %s does not exist\n", service)
-				channel <- 1
+			if detach || noStart {
+				serviceUp(serviceName, serviceConfig, channel)
 			} else {
-				if detach {
-					serviceUp(service, serviceConfig, channel)
-				} else {
-					go serviceUp(service, serviceConfig, channel)
-				}
+				go serviceUp(serviceName, serviceConfig, channel)
 			}
 		}
 	}
@@ -95,188 +237,212 @@ Loop:
 	//删除重复项
 	down.RemoveOrphans(removeOrphans)
 
-}
-
-func serviceUp(serviceName string, service compose.ServiceConfig, channel chan int) {
-	container, exist := compose.GetContainer(serviceName)
-	upToDate := exist && isUpToDate(container, service)
-
-	if upToDate {
-		fmt.Println(compose.FormatServiceName(serviceName) + " is up to date")
-	} else {
-		if exist {
-			force := true
-			fmt.Print(compose.FormatServiceName(serviceName) + " recreating... ")
-			cli.Remove(container.ID, &force, nil)
-		} else {
-			fmt.Print(compose.FormatServiceName(serviceName) + " creating... ")
-		}
-
-		command, err := getCommand(serviceName, service)
+	if wait {
+		err = waitForServices(serviceNames, time.Duration(waitTimeout)*time.Second)
 		if err != nil {
-			fmt.Println(serviceName, ":", err)
+			fmt.Println(err)
 			os.Exit(1)
 		}
-		podmanCmd, err := exec.LookPath("podman")
-		cmd := exec.Command(podmanCmd, command...)
-		cmd.Stderr = os.Stdout
-		err = cmd.Run()
-		if err != nil {
-			fmt.Println(serviceName, ":", err)
-			return
-		}
-		fmt.Print(util.TextColor(32, "done"))
-		fmt.Println()
 	}
-	channel <- 1
-}
 
-// 是否是最新
-func isUpToDate(listContainer cli.ListContainer, service compose.ServiceConfig) bool {
-
-	// 运行中 && 配置未修改 && 镜像也未修改  则是 up to date
-	if listContainer.State == "running" {
-		key := listContainer.Labels[constant.LabelConfigKey]
-		expectKey := service.GetUnique()
-		if key == expectKey {
-			detail, err := cli.Inspect(listContainer.ID, nil)
-			if err != nil {
-				fmt.Println(err)
-				os.Exit(1)
-			}
-			image, err := cli.GetImage(service.Image, nil)
-			if err != nil {
-				fmt.Println(err)
-				os.Exit(1)
-			}
-			if detail.Image == image.ID {
-				return true
-			}
-		}
+	//非 detach 模式，输出所有服务的日志直到容器退出
+	if !detach && !noStart {
+		os.Exit(attach(serviceNames))
 	}
-
-	return false
 }
 
-/*
-*
-获取命令
-*/
-func getCommand(name string, service compose.ServiceConfig) ([]string, error) {
-	command := []string{"run"}
-	if detach {
-		command = append(command, "--detach")
-	}
-	//端口
-	command, err := formatPort(command, &service)
-	if err != nil {
-		return command, err
-	}
+// 启动失败的服务
+var failedServices sync.Map
 
-	//挂载卷
-	command, err = formatVolumes(command, &service)
-	if err != nil {
-		return command, err
-	}
+// 本次创建或重建的服务
+var recreatedServices sync.Map
 
-	//容器名称
-	if strings.TrimSpace(service.ContainerName) != "" {
-		command = append(command, "--name", service.ContainerName)
-	}
+// 本次启动的服务，serviceUp 结束时关闭对应的 channel；在启动任何服务之前创建，之后只读
+var serviceDone = map[string]chan struct{}{}
 
-	//workdir
-	if strings.TrimSpace(service.WorkingDir) != "" {
-		command = append(command, "--workdir", service.WorkingDir)
-	}
+// 本次创建或启动的服务容器(序号最小的一个)，依赖条件只检查这个容器
+var serviceContainers sync.Map
 
-	//Entrypoint
-	if strings.TrimSpace(service.Entrypoint) != "" {
-		command = append(command, "--entrypoint", service.Entrypoint)
+func prepareServices(serviceNames []string) {
+	for _, serviceName := range serviceNames {
+		serviceDone[serviceName] = make(chan struct{})
 	}
+}
 
-	// restart
-	if strings.TrimSpace(service.Restart) != "" {
-		command = append(command, "--restart", service.Restart)
+// 等待本次对服务的创建、启动完成，避免看到即将被删除的旧容器
+func waitForDone(serviceName string) {
+	if done, exist := serviceDone[serviceName]; exist {
+		<-done
 	}
+}
 
-	//标签
-	command = append(command, "--label", constant.LabelComposeDir+"="+compose.GetComposeDir())
-	command = append(command, "--label", constant.LabelComposeServiceName+"="+name)
-	command = append(command, "--label", constant.LabelConfigKey+"="+service.GetUnique())
-
-	//环境
-	env, _ := service.GetEnvironment()
-	for k, v := range env {
-		command = append(command, "--env", k+"="+v)
-	}
-	//镜像
-	command, err = formatImage(command, &service)
+// 等待依赖的服务满足 depends_on 中的条件，只等待本次启动的服务(--no-deps 时依赖的服务不会启动)
+func waitForDependencies(serviceName string, service compose.ServiceConfig, selected map[string]bool) error {
+	deps, err := service.GetDependsOn()
 	if err != nil {
-		return command, err
+		return err
 	}
-
-	//容器命令
-	command, err = formatContainerCommand(command, &service)
-	if err != nil {
-		return command, err
+	dockerCompose := compose.GetDockerCompose()
+	for dep, dependency := range deps {
+		//没有副本的服务不会启动容器
+		depService := dockerCompose.Services[dep]
+		if !selected[dep] || depService.GetReplicas() == 0 {
+			continue
+		}
+		err = waitForCondition(dep, dependency.Condition)
+		if err != nil {
+			if !dependency.Required {
+				fmt.Println(compose.FormatServiceName(serviceName)+" optional dependency", dep, "failed:", err)
+				continue
+			}
+			return fmt.Errorf("%s: dependency %s failed: %v", serviceName, dep, err)
+		}
 	}
-	return command, nil
+	return nil
 }
 
-// 镜像
-func formatContainerCommand(command []string, service *compose.ServiceConfig) ([]string, error) {
-	if len(service.Command) > 0 {
-		for _, cmd := range service.Command {
-			command = append(command, cmd)
+func waitForCondition(serviceName string, condition string) error {
+	waitForDone(serviceName)
+	if _, failed := failedServices.Load(serviceName); failed {
+		return errors.New("service failed to start")
+	}
+	containerID, exist := serviceContainers.Load(serviceName)
+	if !exist {
+		return errors.New("service has no container")
+	}
+
+	//健康检查最长等待时间，超过后不再等待
+	service := compose.GetDockerCompose().Services[serviceName]
+	deadline := time.Now().Add(service.Healthcheck.GetHealthyTimeout())
+	for {
+		detail, err := cli.Inspect(containerID.(string), nil)
+		if err != nil {
+			return err
 		}
+		state := detail.State
+		switch condition {
+		case compose.ConditionServiceStarted:
+			return nil
+		case compose.ConditionServiceHealthy:
+			if state.Health == nil || state.Health.Status == "" {
+				return errors.New("service has no healthcheck")
+			}
+			if state.Health.Status == "healthy" {
+				return nil
+			}
+			if state.Health.Status == "unhealthy" {
+				return fmt.Errorf("container is unhealthy: %s", lastProbeOutput(state.Health))
+			}
+			if !state.Running {
+				return errors.New("container exited before becoming healthy")
+			}
+			if time.Now().After(deadline) {
+				return errors.New("timeout waiting for container to become healthy")
+			}
+		case compose.ConditionServiceCompletedSuccessfully:
+			if !state.Running && state.Status == "exited" {
+				if state.ExitCode != 0 {
+					return fmt.Errorf("container exited with code %d", state.ExitCode)
+				}
+				return nil
+			}
+		}
+		time.Sleep(500 * time.Millisecond)
 	}
-	return command, nil
 }
 
-// 镜像
-func formatImage(command []string, service *compose.ServiceConfig) ([]string, error) {
-	image := strings.TrimSpace(service.Image)
-	if image == "" {
-		return command, errors.New("image is required")
+func serviceUp(serviceName string, service compose.ServiceConfig, channel chan int) {
+	defer func() {
+		if done, exist := serviceDone[serviceName]; exist {
+			close(done)
+		}
+		channel <- 1
+	}()
+	recreatedDeps, err := plan.RecreatedDependencies(service, func(dep string) bool {
+		//前台模式下依赖的服务在另一个 goroutine 中启动，等它结束后再判断是否重建
+		waitForDone(dep)
+		_, recreated := recreatedServices.Load(dep)
+		return recreated
+	})
+	if err != nil {
+		fmt.Println(serviceName, ":", err)
+		failedServices.Store(serviceName, true)
+		return
 	}
-	_, err := cli.GetImage(image, nil)
+	actions, err := plan.ComputeService(serviceName, service, planOptions, recreatedDeps)
 	if err != nil {
-		return command, err
+		fmt.Println(serviceName, ":", err)
+		failedServices.Store(serviceName, true)
+		return
 	}
-	command = append(command, service.Image)
-	return command, nil
-}
 
-// 挂载卷
-func formatVolumes(command []string, service *compose.ServiceConfig) ([]string, error) {
-	for _, volume := range service.Volumes {
-		command = append(command, "-v", volume)
+	for _, action := range actions {
+		containerID, err := applyAction(serviceName, service, action)
+		if err == nil && containerID != "" {
+			serviceContainers.LoadOrStore(serviceName, containerID)
+		}
+		if err != nil {
+			fmt.Println(compose.DisplayName(serviceName, action.Number), ":", err)
+			failedServices.Store(serviceName, true)
+			return
+		}
 	}
-	return command, nil
 }
 
-// 组装端口
-func formatPort(command []string, service *compose.ServiceConfig) ([]string, error) {
-	for _, port := range service.Ports {
-		pair := strings.Split(port, ":")
-		if len(pair) == 1 {
-			_, err := strconv.Atoi(pair[0])
-			if err != nil {
-				return nil, errors.New("port [" + port + "] is Invalid")
-			}
+// 执行单个容器的操作，返回操作后的容器 ID(删除时为空)
+func applyAction(serviceName string, service compose.ServiceConfig, action plan.ServiceAction) (string, error) {
+	name := compose.FormatServiceName(compose.DisplayName(serviceName, action.Number))
+	force := true
+	containerID := ""
+	switch action.Action {
+	case plan.ActionUnchanged:
+		fmt.Println(name + " is up to date")
+		return action.ContainerID, nil
+	case plan.ActionStart:
+		if noStart {
+			return action.ContainerID, nil
 		}
-		if len(pair) == 2 {
-			_, err := strconv.Atoi(pair[0])
-			if err != nil {
-				return nil, errors.New("port [" + port + "] is Invalid")
-			}
-			_, err = strconv.Atoi(pair[1])
-			if err != nil {
-				return nil, errors.New("port [" + port + "] is Invalid")
-			}
+		fmt.Print(name + " starting... ")
+		err := cli.Start(action.ContainerID, nil)
+		if err != nil {
+			return "", err
+		}
+		containerID = action.ContainerID
+	case plan.ActionRemove:
+		fmt.Print(name + " removing... ")
+		err := cli.Remove(action.ContainerID, &force, nil)
+		if err != nil {
+			return "", err
+		}
+	default:
+		if action.Action == plan.ActionRecreate {
+			fmt.Print(name + " recreating... ")
+			cli.Remove(action.ContainerID, &force, nil)
+		} else {
+			fmt.Print(name + " creating... ")
+		}
+
+		//在启动前记录，依赖它的服务等到它启动时就能看到
+		recreatedServices.Store(serviceName, true)
+		var err error
+		containerID, err = createAndStart(serviceName, service, action.Number, !noStart)
+		if err != nil {
+			return "", err
 		}
-		command = append(command, "-p", port)
 	}
+	fmt.Println(util.TextColor(32, "done"))
+	return containerID, nil
+}
 
-	return command, nil
+// 通过 API 创建容器，start 为 true 时同时启动，返回容器 ID
+func createAndStart(serviceName string, service compose.ServiceConfig, number int, start bool) (string, error) {
+	spec, err := compose.GetContainerSpec(serviceName, service, number)
+	if err != nil {
+		return "", err
+	}
+	created, err := cli.CreateContainer(spec)
+	if err != nil || !start {
+		return created.ID, err
+	}
+	return created.ID, cli.Start(created.ID, nil)
 }
@@ -0,0 +1,127 @@
+package up
+
+import (
+	"fmt"
+	"podman-compose/cli"
+	"podman-compose/compose"
+	"podman-compose/util"
+	"sort"
+	"strings"
+	"time"
+)
+
+/*
+*
+等待所有服务 healthy，没有健康检查的服务只需要 running
+timeout 为 0 时一直等待
+*/
+func waitForServices(serviceNames []string, timeout time.Duration) error {
+	var deadline time.Time
+	if timeout > 0 {
+		deadline = time.Now().Add(timeout)
+	}
+	pending := map[string]string{}
+	for _, serviceName := range serviceNames {
+		pending[serviceName] = "waiting"
+	}
+	for {
+		compose.RefreshContainerList()
+		for serviceName := range pending {
+			ready, status, err := serviceReady(serviceName)
+			if err != nil {
+				pending[serviceName] = err.Error()
+				return waitError(pending, "failed")
+			}
+			if ready {
+				fmt.Println(compose.FormatServiceName(serviceName) + " " + util.TextColor(32, status))
+				delete(pending, serviceName)
+			} else {
+				pending[serviceName] = status
+			}
+		}
+		if len(pending) == 0 {
+			return nil
+		}
+		if !deadline.IsZero() && time.Now().After(deadline) {
+			return waitError(pending, "timeout")
+		}
+		time.Sleep(time.Second)
+	}
+}
+
+// 服务是否就绪，多副本的服务所有容器都就绪才算就绪，返回当前状态
+func serviceReady(serviceName string) (bool, string, error) {
+	service := compose.GetDockerCompose().Services[serviceName]
+	containers := compose.GetContainers(serviceName)
+	if len(containers) < service.GetReplicas() {
+		return false, "no container", nil
+	}
+	status := "running"
+	for _, container := range containers {
+		ready, containerStatus, err := containerReady(container)
+		if err != nil || !ready {
+			if len(containers) > 1 {
+				name := compose.DisplayName(serviceName, compose.ContainerNumber(container))
+				containerStatus = name + " " + containerStatus
+				if err != nil {
+					err = fmt.Errorf("%s %v", name, err)
+				}
+			}
+			return false, containerStatus, err
+		}
+		status = containerStatus
+	}
+	return true, status, nil
+}
+
+// 单个容器是否就绪
+func containerReady(container cli.ListContainer) (bool, string, error) {
+	detail, err := cli.Inspect(container.ID, nil)
+	if err != nil {
+		return false, "", err
+	}
+	state := detail.State
+	if !state.Running {
+		if state.Status == "exited" {
+			//一次性任务正常结束
+			if state.ExitCode == 0 {
+				return true, "completed", nil
+			}
+			return false, "", fmt.Errorf("exited with code %d", state.ExitCode)
+		}
+		return false, state.Status, nil
+	}
+	if state.Health == nil || state.Health.Status == "" {
+		return true, "running", nil
+	}
+	switch state.Health.Status {
+	case "healthy":
+		return true, "healthy", nil
+	case "unhealthy":
+		//健康检查已经失败，不再继续等待
+		return false, "unhealthy", fmt.Errorf("unhealthy: %s", lastProbeOutput(state.Health))
+	}
+	return false, state.Health.Status, nil
+}
+
+// 最后一次健康检查的输出
+func lastProbeOutput(health *cli.HealthCheckResults) string {
+	if len(health.Log) == 0 {
+		return ""
+	}
+	last := health.Log[len(health.Log)-1]
+	return fmt.Sprintf("exit code %d, output: %s", last.ExitCode, strings.TrimSpace(last.Output))
+}
+
+func waitError(pending map[string]string, reason string) error {
+	names := make([]string, 0, len(pending))
+	for name := range pending {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	lines := []string{"services are not healthy (" + reason + "):"}
+	for _, name := range names {
+		lines = append(lines, "  "+compose.FormatServiceName(name)+" "+pending[name])
+	}
+	return fmt.Errorf("%s", strings.Join(lines, "\n"))
+}
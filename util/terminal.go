@@ -0,0 +1,24 @@
+package util
+
+import (
+	"golang.org/x/term"
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+// ResizeOnWinch 按当前终端大小调用 resize，窗口大小变化时再次调用
+func ResizeOnWinch(resize func(height, width int)) {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGWINCH)
+	defer signal.Stop(signals)
+	for {
+		width, height, err := term.GetSize(int(os.Stdout.Fd()))
+		if err == nil {
+			resize(height, width)
+		}
+		if _, ok := <-signals; !ok {
+			return
+		}
+	}
+}
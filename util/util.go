@@ -2,6 +2,7 @@ package util
 
 import (
 	"fmt"
+	"strconv"
 	"strings"
 )
 
@@ -44,3 +45,93 @@ func GetMapSize(str string, length int, middle bool) string {
 
 	}
 }
+
+// SplitCommand 按 shell 的规则拆分命令，支持单引号、双引号和反斜杠转义
+func SplitCommand(str string) ([]string, error) {
+	var args []string
+	var current strings.Builder
+	inArg := false
+	var quote rune
+	escaped := false
+	for _, c := range str {
+		switch {
+		case escaped:
+			current.WriteRune(c)
+			escaped = false
+		case c == '\\' && quote != '\'':
+			escaped = true
+			inArg = true
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			} else {
+				current.WriteRune(c)
+			}
+		case c == '\'' || c == '"':
+			quote = c
+			inArg = true
+		case c == ' ' || c == '\t' || c == '\n':
+			if inArg {
+				args = append(args, current.String())
+				current.Reset()
+				inArg = false
+			}
+		default:
+			current.WriteRune(c)
+			inArg = true
+		}
+	}
+	if quote != 0 || escaped {
+		return nil, fmt.Errorf("unterminated quote or escape in %q", str)
+	}
+	if inArg {
+		args = append(args, current.String())
+	}
+	return args, nil
+}
+
+// PrefixWriter 每一行输出前加上前缀
+type PrefixWriter struct {
+	Prefix  string
+	partial string
+}
+
+func (w *PrefixWriter) Write(p []byte) (int, error) {
+	lines := strings.Split(w.partial+string(p), "\n")
+	w.partial = lines[len(lines)-1]
+	for _, line := range lines[:len(lines)-1] {
+		fmt.Println(w.Prefix + line)
+	}
+	return len(p), nil
+}
+
+// 容量单位，按 1024 进制
+var sizeUnits = map[string]float64{
+	"":  1,
+	"k": 1 << 10,
+	"m": 1 << 20,
+	"g": 1 << 30,
+	"t": 1 << 40,
+	"p": 1 << 50,
+}
+
+// ParseSize 解析容量，例如 512m、1g、1.5GB、1024，不带单位时为字节
+func ParseSize(str string) (int64, error) {
+	value := strings.ToLower(strings.TrimSpace(str))
+	value = strings.TrimSuffix(value, "ib")
+	value = strings.TrimSuffix(value, "b")
+	idx := strings.IndexFunc(value, func(c rune) bool {
+		return (c < '0' || c > '9') && c != '.'
+	})
+	unit := ""
+	if idx != -1 {
+		unit = strings.TrimSpace(value[idx:])
+		value = value[:idx]
+	}
+	multiple, ok := sizeUnits[unit]
+	number, err := strconv.ParseFloat(value, 64)
+	if !ok || err != nil || number < 0 {
+		return 0, fmt.Errorf("invalid size: %q", str)
+	}
+	return int64(number * multiple), nil
+}